@@ -1,66 +1,245 @@
 package firstexample
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 )
 
+// Void elements cannot have any content, so they are rendered without a closing tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+func isVoidElement(name string) bool {
+	return voidElements[strings.ToLower(name)]
+}
+
+/*
+Values are escaped when they are rendered, but names cannot be: a name like `x" onclick="alert(1)` would inject markup.
+So tag and attribute names are restricted to the same characters the parser accepts in them.
+*/
+
+var ErrInvalidName = errors.New("invalid name")
+
+func isValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isNameChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkName(what, name string) error {
+	if !isValidName(name) {
+		return fmt.Errorf("%w: %s %q", ErrInvalidName, what, name)
+	}
+	return nil
+}
+
+// Attributes are kept in a slice so that they are rendered in the order they were added.
+type htmlAttribute struct {
+	name, value string
+}
+
+// Most nodes of the tree are elements, but text mixed between elements and comments need nodes of their own.
+type nodeKind int
+
 const (
-	indentSize = 2
+	elementNode nodeKind = iota
+	textNode
+	commentNode
 )
 
 // The entire HTML construct is a tree.
 type HtmlElement struct {
+	kind       nodeKind
 	name, text string
-	elements   []HtmlElement // Nested Elements
+	attributes []htmlAttribute
+	elements   []*HtmlElement // Nested Elements
 }
 
-func (e *HtmlElement) String() string {
-	return e.string(0)
+func newHtmlElement(name, text string) *HtmlElement {
+	return &HtmlElement{name: name, text: text, elements: []*HtmlElement{}}
 }
 
-func (e *HtmlElement) string(indent int) string {
+// Attr returns the value of the named attribute and whether it is set.
+func (e *HtmlElement) Attr(name string) (string, bool) {
+	for _, a := range e.attributes {
+		if a.name == name {
+			return a.value, true
+		}
+	}
+	return "", false
+}
+
+// Setting an attribute that already exists replaces its value in place.
+func (e *HtmlElement) setAttr(name, value string) {
+	for i, a := range e.attributes {
+		if a.name == name {
+			e.attributes[i].value = value
+			return
+		}
+	}
+	e.attributes = append(e.attributes, htmlAttribute{name, value})
+}
+
+// HTML renders the element in pretty mode, and returns the error that stopped the rendering of an invalid tree.
+func (e *HtmlElement) HTML() (string, error) {
 	sb := strings.Builder{}
-	i := strings.Repeat(" ", indentSize*indent)
-	sb.WriteString(fmt.Sprintf("%s<%s>\n", i, e.name))
-	if len(e.text) > 0 {
-		sb.WriteString(strings.Repeat(" ", indentSize*(indent+1)))
-		sb.WriteString(e.text)
-		sb.WriteString("\n")
+	if _, err := e.WriteTo(&sb); err != nil {
+		return "", err
 	}
+	return sb.String(), nil
+}
 
-	for _, el := range e.elements {
-		sb.WriteString(el.string(indent + 1)) // Recursion
+// String cannot return an error, so an invalid tree is rendered as a marker in the style of fmt, such as "%!(html: ...)".
+// A truncated document would look valid and fail somewhere far away. Use HTML, WriteTo or Render to get the error.
+func (e *HtmlElement) String() string {
+	s, err := e.HTML()
+	if err != nil {
+		return "%!(html: " + htmlEscaper.Replace(err.Error()) + ")"
 	}
-	sb.WriteString(fmt.Sprintf("%s</%s>\n", i, e.name))
-	return sb.String()
+	return s
 }
 
+/*
+A builder always works on its current element. The builder returned by NewHtmlBuilder works on the root,
+while Child opens a new builder working on a nested element, which remembers its parent so that End
+can take us back up the tree.
+*/
 type HtmlBuilder struct {
 	rootName string
-	root     HtmlElement
+	root     *HtmlElement
+	current  *HtmlElement
+	parent   *HtmlBuilder
+	// The first invalid name given to the chain, kept by the root builder. See Err.
+	err error
 }
 
 func NewHtmlBuilder(rootName string) *HtmlBuilder {
-	return &HtmlBuilder{rootName, HtmlElement{rootName, "", []HtmlElement{}}}
+	b := NewHtmlBuilderFrom(newHtmlElement(rootName, ""))
+	b.fail(checkName("tag name", rootName))
+	return b
 }
 
+// NewHtmlBuilderFrom lets us keep building on an existing tree, for example one returned by ParseHtml.
+func NewHtmlBuilderFrom(root *HtmlElement) *HtmlBuilder {
+	return &HtmlBuilder{rootName: root.name, root: root, current: root}
+}
+
+// The whole document is rendered, no matter which builder in the chain we call it on.
 func (b *HtmlBuilder) String() string {
-	return b.root.String()
+	return b.top().root.String()
+}
+
+// HTML also fails when the chain was given an invalid name, since the element or attribute is missing from the tree.
+func (b *HtmlBuilder) HTML() (string, error) {
+	if err := b.Err(); err != nil {
+		return "", err
+	}
+	return b.top().root.HTML()
+}
+
+func (b *HtmlBuilder) WriteTo(w io.Writer) (int64, error) {
+	return b.top().root.WriteTo(w)
+}
+
+func (b *HtmlBuilder) Render(w io.Writer, opts RenderOptions) (int64, error) {
+	return b.top().root.Render(w, opts)
+}
+
+func (b *HtmlBuilder) top() *HtmlBuilder {
+	for b.parent != nil {
+		b = b.parent
+	}
+	return b
+}
+
+/*
+The fluent methods cannot return an error, so the builder records the first one instead, like bufio.Scanner does.
+Elements and attributes with invalid names are left out of the tree, and rendering would refuse them anyway.
+*/
+
+func (b *HtmlBuilder) fail(err error) {
+	if top := b.top(); err != nil && top.err == nil {
+		top.err = err
+	}
+}
+
+// Err returns the first invalid tag or attribute name given to any builder in the chain.
+func (b *HtmlBuilder) Err() error {
+	return b.top().err
 }
 
 func (b *HtmlBuilder) AddChild(childName, childText string) {
-	e := HtmlElement{childName, childText, []HtmlElement{}}
-	b.root.elements = append(b.root.elements, e)
+	if err := checkName("tag name", childName); err != nil {
+		b.fail(err)
+		return
+	}
+	b.current.elements = append(b.current.elements, newHtmlElement(childName, childText))
 }
 
 // Adding the return it is possible to concatenate calls to this function
 func (b *HtmlBuilder) AddChildFluent(childName, childText string) *HtmlBuilder {
-	e := HtmlElement{childName, childText, []HtmlElement{}}
-	b.root.elements = append(b.root.elements, e)
+	b.AddChild(childName, childText)
+	return b
+}
+
+// Child adds a nested element and returns a builder for it. Call End on it to go back to this builder.
+// With an invalid name, the nested builder works on a detached element, so nothing it adds ends up in the tree.
+func (b *HtmlBuilder) Child(childName string) *HtmlBuilder {
+	e := newHtmlElement(childName, "")
+	if err := checkName("tag name", childName); err != nil {
+		b.fail(err)
+	} else {
+		b.current.elements = append(b.current.elements, e)
+	}
+	return &HtmlBuilder{rootName: b.rootName, current: e, parent: b}
+}
+
+// End returns the builder of the parent element. On the root builder it just returns the receiver.
+func (b *HtmlBuilder) End() *HtmlBuilder {
+	if b.parent == nil {
+		return b
+	}
+	return b.parent
+}
+
+func (b *HtmlBuilder) Text(text string) *HtmlBuilder {
+	b.current.text = text
 	return b
 }
 
+func (b *HtmlBuilder) Attr(name, value string) *HtmlBuilder {
+	if err := checkName("attribute name", name); err != nil {
+		b.fail(err)
+		return b
+	}
+	b.current.setAttr(name, value)
+	return b
+}
+
+func (b *HtmlBuilder) ID(id string) *HtmlBuilder {
+	return b.Attr("id", id)
+}
+
+// Class appends to the class list instead of replacing it, so it can be called several times.
+func (b *HtmlBuilder) Class(class string) *HtmlBuilder {
+	if existing, ok := b.current.Attr("class"); ok && existing != "" {
+		class = existing + " " + class
+	}
+	return b.Attr("class", class)
+}
+
 func main_() {
 	b := NewHtmlBuilder("ul")
 	// Adding elements
@@ -68,4 +247,28 @@ func main_() {
 	b.AddChild("li", "mundo")
 	// Chaining calls with fluent interface
 	b.AddChildFluent("li", "hello").AddChildFluent("li", "world")
+
+	// Opening nested builders, giving them attributes and then going back to the parent
+	page := NewHtmlBuilder("div").Class("card")
+	page.
+		Child("h1").ID("title").Text("Fish & Chips").End().
+		Child("img").Attr("src", "/fish.png").Attr("alt", `"Fresh" fish`).End().
+		Child("p").Attr("data-user", "<script>").Text("<b>not bold</b>").End()
+	fmt.Println(page.String())
+
+	// Names cannot be escaped, so invalid ones are refused instead of injecting markup
+	bad := NewHtmlBuilder("div").Attr(`x" onclick="alert(1)`, "v")
+	fmt.Println(bad.Err())
+
+	// The same tree can be streamed to any writer, either minified or with a different indent
+	page.Render(os.Stdout, RenderOptions{Mode: Compact})
+	page.Render(os.Stdout, RenderOptions{Mode: Pretty, Indent: 4})
+
+	// A template can also be parsed into a tree and then extended with the builder
+	template, err := ParseHtml(strings.NewReader(`<ul class="menu"><li>home</li></ul>`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	NewHtmlBuilderFrom(template).AddChildFluent("li", "about").Render(os.Stdout, RenderOptions{Mode: Compact})
 }
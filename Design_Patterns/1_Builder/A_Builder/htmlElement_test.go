@@ -0,0 +1,69 @@
+package firstexample
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInvalidNamesAreRefused(t *testing.T) {
+	b := NewHtmlBuilder("div").Attr(`x" onclick="alert(1)`, "v")
+	if !errors.Is(b.Err(), ErrInvalidName) {
+		t.Fatalf("Err() = %v, want ErrInvalidName", b.Err())
+	}
+	if got := b.String(); strings.Contains(got, "onclick") {
+		t.Fatalf("invalid attribute was rendered: %q", got)
+	}
+
+	child := NewHtmlBuilder("ul").Child("li><script").Text("x").End()
+	if !errors.Is(child.Err(), ErrInvalidName) {
+		t.Fatalf("Err() = %v, want ErrInvalidName", child.Err())
+	}
+	if got := child.String(); strings.Contains(got, "script") {
+		t.Fatalf("invalid tag was rendered: %q", got)
+	}
+
+	if err := NewHtmlElement("p", "").SetAttr("a b", "v"); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("SetAttr() = %v, want ErrInvalidName", err)
+	}
+	if err := NewHtmlBuilder("p").Attr("data-x", "1").Err(); err != nil {
+		t.Fatalf("valid name refused: %v", err)
+	}
+}
+
+func TestRenderRefusesInvalidNames(t *testing.T) {
+	e := NewHtmlElement("div", "")
+	e.AppendChild(NewHtmlElement(`p onclick="x"`, ""))
+	var sb strings.Builder
+	if _, err := e.Render(&sb, RenderOptions{Mode: Compact}); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("Render() = %v, want ErrInvalidName", err)
+	}
+}
+
+func TestHTMLReportsWhatStringCannot(t *testing.T) {
+	b := NewHtmlBuilder("ul").AddChildFluent("li", "one")
+	got, err := b.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<ul>\n  <li>\n    one\n  </li>\n</ul>\n"; got != want || b.String() != want {
+		t.Errorf("HTML() = %q, String() = %q, want %q", got, b.String(), want)
+	}
+
+	// The builder left the invalid attribute out, so the tree renders, but HTML still reports it
+	if _, err := NewHtmlBuilder("p").Attr("a b", "v").HTML(); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("HtmlBuilder.HTML() = %v, want ErrInvalidName", err)
+	}
+
+	// A tree changed by hand fails halfway through, and String must not pass the first half off as the document
+	e := NewHtmlElement("div", "")
+	e.AppendChild(NewHtmlElement("p", "before"))
+	e.AppendChild(NewHtmlElement(`<script>`, ""))
+	if s, err := e.HTML(); !errors.Is(err, ErrInvalidName) || s != "" {
+		t.Errorf("HTML() = %q, %v, want ErrInvalidName", s, err)
+	}
+	s := e.String()
+	if !strings.HasPrefix(s, "%!(html: ") || strings.Contains(s, "<div>") || strings.Contains(s, "<script>") {
+		t.Errorf("String() = %q, want an escaped error marker and no markup", s)
+	}
+}
@@ -0,0 +1,340 @@
+package firstexample
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+The builder goes from code to markup. The parser goes the other way: it reads an HTML fragment and builds
+the same HtmlElement tree the builder would have built, so it can be changed with the builder API and rendered again.
+
+Only a reasonable subset of HTML is understood: elements, quoted, unquoted and boolean attributes, text with
+character references, comments, a leading doctype, void elements and self-closing tags.
+Every element other than a void element must be closed explicitly, so there are no implied end tags as in browsers.
+
+Whitespace is normalized in two places. Text made of nothing but whitespace between tags is dropped, and the text
+of an element that contains nothing else is trimmed. Pretty rendering puts such text on a line of its own, so
+this is what lets its output be parsed back into the tree it came from. Text mixed with elements is kept as it is.
+*/
+
+// ParseError reports what went wrong and where, with both the line and the column starting at 1.
+type ParseError struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("html: %d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ParseHtml parses a document that has exactly one root element. Comments and whitespace around it are ignored.
+func ParseHtml(r io.Reader) (*HtmlElement, error) {
+	p, err := newParser(r)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := p.parseFragment()
+	if err != nil {
+		return nil, err
+	}
+
+	var root *HtmlElement
+	for i, n := range nodes {
+		switch {
+		case n.kind == commentNode:
+		case n.kind == textNode:
+			return nil, p.errorAt(p.topLevel[i], "text outside of the root element")
+		case root != nil:
+			return nil, p.errorAt(p.topLevel[i], fmt.Sprintf("more than one root element (<%s> and <%s>)", root.name, n.name))
+		default:
+			root = n
+		}
+	}
+	if root == nil {
+		return nil, p.errorAt(len(p.src), "no root element")
+	}
+	return root, nil
+}
+
+// ParseHtmlFragment parses any number of sibling nodes, including text and comments at the top level.
+func ParseHtmlFragment(r io.Reader) ([]*HtmlElement, error) {
+	p, err := newParser(r)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseFragment()
+}
+
+type parser struct {
+	src string
+	pos int
+	// Offsets of the top level nodes, so that ParseHtml can point at the one it does not accept.
+	topLevel []int
+}
+
+func newParser(r io.Reader) (*parser, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{src: string(data)}, nil
+}
+
+// The line and column are only worked out when there actually is an error.
+func (p *parser) errorAt(offset int, msg string) *ParseError {
+	line, col := 1, 1
+	for _, r := range p.src[:offset] {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return &ParseError{Line: line, Column: col, Msg: msg}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) rest() string {
+	return p.src[p.pos:]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && isSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *parser) parseFragment() ([]*HtmlElement, error) {
+	p.skipDoctype()
+	return p.parseNodes(nil, 0)
+}
+
+func (p *parser) skipDoctype() {
+	p.skipSpace()
+	if len(p.rest()) >= 9 && strings.EqualFold(p.rest()[:9], "<!doctype") {
+		if end := strings.IndexByte(p.rest(), '>'); end >= 0 {
+			p.pos += end + 1
+		}
+	}
+}
+
+// parseNodes reads nodes until the end tag of parent, or until the end of the input when parent is nil.
+// The offset of the start tag of parent is only used to report that it was never closed.
+func (p *parser) parseNodes(parent *HtmlElement, parentStart int) ([]*HtmlElement, error) {
+	nodes := []*HtmlElement{}
+	for !p.eof() {
+		rest := p.rest()
+		if parent == nil && !strings.HasPrefix(rest, "</") {
+			p.topLevel = append(p.topLevel, p.pos)
+		}
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			n, err := p.parseComment()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		case strings.HasPrefix(rest, "</"):
+			start := p.pos
+			name, err := p.parseEndTag()
+			if err != nil {
+				return nil, err
+			}
+			if parent == nil {
+				return nil, p.errorAt(start, fmt.Sprintf("unexpected end tag </%s>", name))
+			}
+			if name != parent.name {
+				return nil, p.errorAt(start, fmt.Sprintf("end tag </%s> does not match <%s>", name, parent.name))
+			}
+			return nodes, nil
+		case strings.HasPrefix(rest, "<"):
+			n, err := p.parseElement()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		default:
+			start := p.pos
+			end := strings.IndexByte(rest, '<')
+			if end < 0 {
+				end = len(rest)
+			}
+			p.pos += end
+			raw := rest[:end]
+			if strings.TrimSpace(raw) == "" {
+				if parent == nil {
+					p.topLevel = p.topLevel[:len(p.topLevel)-1]
+				}
+				continue // Whitespace between tags is only formatting
+			}
+			if !utf8.ValidString(raw) {
+				return nil, p.errorAt(start, "text is not valid UTF-8")
+			}
+			nodes = append(nodes, &HtmlElement{kind: textNode, text: html.UnescapeString(raw)})
+		}
+	}
+	if parent != nil {
+		return nil, p.errorAt(parentStart, fmt.Sprintf("element <%s> is never closed", parent.name))
+	}
+	return nodes, nil
+}
+
+func (p *parser) parseComment() (*HtmlElement, error) {
+	start := p.pos
+	p.pos += len("<!--")
+	end := strings.Index(p.rest(), "-->")
+	if end < 0 {
+		return nil, p.errorAt(start, "unterminated comment")
+	}
+	text := p.rest()[:end]
+	if !isValidComment(text) {
+		return nil, p.errorAt(start, `comment cannot contain "--", start with ">" or "->", or end with "-"`)
+	}
+	p.pos += end + len("-->")
+	return &HtmlElement{kind: commentNode, text: text}, nil
+}
+
+func (p *parser) parseEndTag() (string, error) {
+	p.pos += len("</")
+	name, err := p.parseName("tag name")
+	if err != nil {
+		return "", err
+	}
+	p.skipSpace()
+	if p.peek() != '>' {
+		return "", p.errorAt(p.pos, fmt.Sprintf("expected '>' to close </%s>", name))
+	}
+	p.pos++
+	return strings.ToLower(name), nil
+}
+
+func (p *parser) parseElement() (*HtmlElement, error) {
+	start := p.pos
+	p.pos++ // '<'
+	name, err := p.parseName("tag name")
+	if err != nil {
+		return nil, err
+	}
+	e := newHtmlElement(strings.ToLower(name), "")
+
+	selfClosing := false
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorAt(start, fmt.Sprintf("unterminated start tag <%s>", e.name))
+		}
+		if p.peek() == '>' {
+			p.pos++
+			break
+		}
+		if strings.HasPrefix(p.rest(), "/>") {
+			p.pos += 2
+			selfClosing = true
+			break
+		}
+		if err := p.parseAttribute(e); err != nil {
+			return nil, err
+		}
+	}
+	if selfClosing || isVoidElement(e.name) {
+		return e, nil
+	}
+
+	children, err := p.parseNodes(e, start)
+	if err != nil {
+		return nil, err
+	}
+
+	// A lone piece of text is what AddChild would have created, so it goes into the text of the element itself.
+	// It is trimmed, see the top of this file.
+	if len(children) == 1 && children[0].kind == textNode {
+		e.text = strings.TrimSpace(children[0].text)
+	} else {
+		e.elements = children
+	}
+	return e, nil
+}
+
+func (p *parser) parseAttribute(e *HtmlElement) error {
+	name, err := p.parseName("attribute name")
+	if err != nil {
+		return err
+	}
+	name = strings.ToLower(name)
+	value := ""
+
+	p.skipSpace()
+	if p.peek() == '=' {
+		p.pos++
+		p.skipSpace()
+		if value, err = p.parseAttributeValue(); err != nil {
+			return err
+		}
+	}
+	// As in browsers, the first occurrence of a repeated attribute wins.
+	if _, ok := e.Attr(name); !ok {
+		e.attributes = append(e.attributes, htmlAttribute{name, html.UnescapeString(value)})
+	}
+	return nil
+}
+
+func (p *parser) parseAttributeValue() (string, error) {
+	start := p.pos
+	if q := p.peek(); q == '"' || q == '\'' {
+		p.pos++
+		end := strings.IndexByte(p.rest(), q)
+		if end < 0 {
+			return "", p.errorAt(start, "unterminated attribute value")
+		}
+		value := p.rest()[:end]
+		p.pos += end + 1
+		return value, nil
+	}
+	for !p.eof() && !isSpace(p.peek()) && p.peek() != '>' && !strings.HasPrefix(p.rest(), "/>") {
+		if c := p.peek(); c == '"' || c == '\'' || c == '<' || c == '=' || c == '`' {
+			return "", p.errorAt(p.pos, fmt.Sprintf("unexpected %q in unquoted attribute value", c))
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorAt(start, "missing attribute value")
+	}
+	return p.src[start:p.pos], nil
+}
+
+func (p *parser) parseName(what string) (string, error) {
+	start := p.pos
+	for !p.eof() && isNameChar(p.peek()) {
+		p.pos++
+	}
+	if p.pos == start {
+		if p.eof() {
+			return "", p.errorAt(start, "unexpected end of input, expected "+what)
+		}
+		return "", p.errorAt(start, fmt.Sprintf("unexpected %q, expected %s", p.peek(), what))
+	}
+	return p.src[start:p.pos], nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isNameChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == ':'
+}
@@ -0,0 +1,133 @@
+package firstexample
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func compact(t *testing.T, e *HtmlElement) string {
+	t.Helper()
+	var sb strings.Builder
+	if _, err := e.Render(&sb, RenderOptions{Mode: Compact}); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestParseHtml(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"doctype", "<!DOCTYPE html>\n<html><body><p>hi</p></body></html>", `<html><body><p>hi</p></body></html>`},
+		{"comments around the root", "<!-- top --> <p>x</p>\n<!-- bottom -->", `<p>x</p>`},
+		{"attributes", `<input type=checkbox checked value='a "b"'>`, `<input type="checkbox" checked="" value="a &#34;b&#34;">`},
+		{"repeated attribute", `<p id=a id=b></p>`, `<p id="a"></p>`},
+		{"upper case names", `<DIV Class="x"></div>`, `<div class="x"></div>`},
+		{"character references", `<p title="&lt;&#39;&gt;">a &amp; b &#x263A;</p>`, `<p title="&lt;&#39;&gt;">a &amp; b ☺</p>`},
+		{"formatting whitespace", "<ul>\n  <li> one </li>\n  <li>two</li>\n</ul>", `<ul><li>one</li><li>two</li></ul>`},
+		{"mixed text", `<p>Hello <b>world</b> !</p>`, `<p>Hello <b>world</b> !</p>`},
+		{"comment inside", `<p><!-- a - b -->x</p>`, `<p><!-- a - b -->x</p>`},
+		{"void and self-closing", `<p>a<br>b<hr/><span /></p>`, `<p>a<br>b<hr><span></span></p>`},
+	}
+	for _, tt := range tests {
+		e, err := ParseHtml(strings.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := compact(t, e); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseHtmlFragment(t *testing.T) {
+	nodes, err := ParseHtmlFragment(strings.NewReader("before <!-- note --><p>x</p>\n<br>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := []nodeKind{}
+	for _, n := range nodes {
+		kinds = append(kinds, n.kind)
+	}
+	if want := []nodeKind{textNode, commentNode, elementNode, elementNode}; !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("node kinds %v, want %v", kinds, want)
+	}
+	if nodes[0].text != "before " || nodes[1].text != " note " || nodes[3].name != "br" {
+		t.Errorf("got %q, %q and <%s>", nodes[0].text, nodes[1].text, nodes[3].name)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input        string
+		line, column int
+		msg          string
+	}{
+		{"<div>", 1, 1, "element <div> is never closed"},
+		{"<div>\n  <p>text</div>", 2, 10, "end tag </div> does not match <p>"},
+		{"<p>héllo</q>", 1, 9, "end tag </q> does not match <p>"}, // Columns count characters, not bytes
+		{"</p>", 1, 1, "unexpected end tag </p>"},
+		{"<p>a</p><p>b</p>", 1, 9, "more than one root element (<p> and <p>)"},
+		{"hello <p></p>", 1, 1, "text outside of the root element"},
+		{"  \n", 2, 1, "no root element"},
+		{"<p><!-- x</p>", 1, 4, "unterminated comment"},
+		{"<p><!-- a -- b --></p>", 1, 4, `comment cannot contain "--", start with ">" or "->", or end with "-"`},
+		{`<a href="x></a>`, 1, 9, "unterminated attribute value"},
+		{`<a href=x"y></a>`, 1, 10, `unexpected '"' in unquoted attribute value`},
+		{"<p\n  class=></p>", 2, 9, "missing attribute value"},
+		{"<p @></p>", 1, 4, `unexpected '@', expected attribute name`},
+		{"<>", 1, 2, `unexpected '>', expected tag name`},
+		{"<p", 1, 1, "unterminated start tag <p>"},
+		{"<p></p x>", 1, 8, "expected '>' to close </p>"},
+		{"<p>\xff</p>", 1, 4, "text is not valid UTF-8"},
+	}
+	for _, tt := range tests {
+		_, err := ParseHtml(strings.NewReader(tt.input))
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: got %v, want a ParseError", tt.input, err)
+			continue
+		}
+		if perr.Line != tt.line || perr.Column != tt.column || perr.Msg != tt.msg {
+			t.Errorf("%q: got %d:%d %q, want %d:%d %q", tt.input, perr.Line, perr.Column, perr.Msg, tt.line, tt.column, tt.msg)
+		}
+	}
+}
+
+func TestParseRenderRoundTrip(t *testing.T) {
+	inputs := []string{
+		`<div class="card" data-x="&quot;q&quot;"><h1>Fish &amp; Chips</h1><img src="/fish.png"></div>`,
+		`<p>Hello <b>world</b> and <i>more</i><!-- note --></p>`,
+		"<ul>\n  <li>one</li>\n  <li>two &lt; three</li>\n</ul>",
+		`<table><tr><td>1</td><td>2</td></tr><tr><td colspan=2>3</td></tr></table>`,
+	}
+	for _, input := range inputs {
+		first, err := ParseHtml(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		rendered := compact(t, first)
+		second, err := ParseHtml(strings.NewReader(rendered))
+		if err != nil {
+			t.Fatalf("%s: %v", rendered, err)
+		}
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("%s changed after rendering it as %s", input, rendered)
+		}
+		if again := compact(t, second); again != rendered {
+			t.Errorf("%s rendered as %s the first time and as %s the second", input, rendered, again)
+		}
+	}
+
+	// The pretty output of a builder tree parses back into the same tree, because lone text is trimmed
+	parsed, err := ParseHtml(strings.NewReader(card().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, card()) {
+		t.Errorf("pretty output parsed as\n%s", parsed)
+	}
+}
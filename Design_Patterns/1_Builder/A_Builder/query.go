@@ -0,0 +1,278 @@
+package firstexample
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Once a tree has been built or parsed, it can still be post-processed. Queries find elements with a small subset
+of CSS selectors, and the mutation methods below insert, replace and remove the nodes that were found.
+
+Supported selectors are compounds of a tag name (or *), #id and any number of .class parts, such as "li.item.active",
+joined by the descendant combinator (a space) or the child combinator (>), as in "nav ul > li".
+The element a query is called on is a candidate too, so "ul > li" works when the root itself is the ul.
+*/
+
+type compoundSelector struct {
+	tag, id string
+	classes []string
+}
+
+type selector struct {
+	parts []compoundSelector
+	// combinators[i] joins parts[i] and parts[i+1], and is either ' ' or '>'.
+	combinators []byte
+}
+
+func parseSelector(s string) (*selector, error) {
+	sel := &selector{}
+	fail := func(msg string) (*selector, error) {
+		return nil, fmt.Errorf("invalid selector %q: %s", s, msg)
+	}
+
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return fail("empty selector")
+	}
+	for {
+		var c compoundSelector
+		i := 0
+		if rest[0] == '*' {
+			i = 1
+		} else {
+			for i < len(rest) && isNameChar(rest[i]) {
+				i++
+			}
+		}
+		c.tag = strings.ToLower(rest[:i])
+		if c.tag == "*" {
+			c.tag = ""
+		} else if c.tag == "" && (i >= len(rest) || rest[i] != '#' && rest[i] != '.') {
+			return fail(fmt.Sprintf("expected a tag, id or class at %q", rest))
+		}
+		rest = rest[i:]
+
+		for len(rest) > 0 && (rest[0] == '#' || rest[0] == '.') {
+			kind := rest[0]
+			i := 1
+			for i < len(rest) && isNameChar(rest[i]) {
+				i++
+			}
+			if i == 1 {
+				return fail(fmt.Sprintf("missing name after %q", kind))
+			}
+			if kind == '#' {
+				if c.id != "" {
+					return fail("more than one id in " + rest)
+				}
+				c.id = rest[1:i]
+			} else {
+				c.classes = append(c.classes, rest[1:i])
+			}
+			rest = rest[i:]
+		}
+		sel.parts = append(sel.parts, c)
+
+		trimmed := strings.TrimLeft(rest, " \t\n")
+		if trimmed == "" {
+			return sel, nil
+		}
+		switch {
+		case trimmed[0] == '>':
+			sel.combinators = append(sel.combinators, '>')
+			rest = strings.TrimLeft(trimmed[1:], " \t\n")
+			if rest == "" {
+				return fail("nothing after '>'")
+			}
+		case len(trimmed) < len(rest):
+			sel.combinators = append(sel.combinators, ' ')
+			rest = trimmed
+		default:
+			return fail(fmt.Sprintf("unexpected %q", trimmed[0]))
+		}
+	}
+}
+
+func (c *compoundSelector) matches(e *HtmlElement) bool {
+	if e.kind != elementNode || c.tag != "" && c.tag != e.name {
+		return false
+	}
+	if c.id != "" {
+		if id, _ := e.Attr("id"); id != c.id {
+			return false
+		}
+	}
+	if len(c.classes) > 0 {
+		class, _ := e.Attr("class")
+		have := strings.Fields(class)
+		for _, want := range c.classes {
+			if !contains(have, want) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Selectors are matched from right to left: e has to match the last part, and then its ancestors the earlier ones.
+func (s *selector) matches(e *HtmlElement, ancestors []*HtmlElement, part int) bool {
+	if !s.parts[part].matches(e) {
+		return false
+	}
+	if part == 0 {
+		return true
+	}
+	if s.combinators[part-1] == '>' {
+		n := len(ancestors)
+		return n > 0 && s.matches(ancestors[n-1], ancestors[:n-1], part-1)
+	}
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if s.matches(ancestors[i], ancestors[:i], part-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryAll returns every element matching the selector, in document order.
+func (e *HtmlElement) QueryAll(selector string) ([]*HtmlElement, error) {
+	sel, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	var found []*HtmlElement
+	var walk func(n *HtmlElement, ancestors []*HtmlElement)
+	walk = func(n *HtmlElement, ancestors []*HtmlElement) {
+		if sel.matches(n, ancestors, len(sel.parts)-1) {
+			found = append(found, n)
+		}
+		ancestors = append(ancestors, n)
+		for _, child := range n.elements {
+			walk(child, ancestors)
+		}
+	}
+	walk(e, nil)
+	return found, nil
+}
+
+// Query returns the first element matching the selector, or nil when there is none.
+func (e *HtmlElement) Query(selector string) (*HtmlElement, error) {
+	found, err := e.QueryAll(selector)
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return found[0], nil
+}
+
+//---------------------------------------------------------------------------------//
+// Accessors, so that code outside of this package can inspect what a query returned.
+
+func (e *HtmlElement) Name() string {
+	return e.name
+}
+
+func (e *HtmlElement) Text() string {
+	return e.text
+}
+
+func (e *HtmlElement) SetText(text string) {
+	e.text = text
+}
+
+// SetAttr refuses names that could not be rendered safely, see ErrInvalidName.
+func (e *HtmlElement) SetAttr(name, value string) error {
+	if err := checkName("attribute name", name); err != nil {
+		return err
+	}
+	e.setAttr(name, value)
+	return nil
+}
+
+func (e *HtmlElement) RemoveAttr(name string) {
+	for i, a := range e.attributes {
+		if a.name == name {
+			e.attributes = append(e.attributes[:i], e.attributes[i+1:]...)
+			return
+		}
+	}
+}
+
+// Children returns a copy of the list of nested nodes, so appending to it does not change the tree.
+func (e *HtmlElement) Children() []*HtmlElement {
+	return append([]*HtmlElement{}, e.elements...)
+}
+
+//---------------------------------------------------------------------------------//
+/*
+Nodes do not know their parents, so the mutations are called on an element containing the target (usually the root)
+and look the parent up first. They report whether the target was found below the receiver.
+*/
+
+func (e *HtmlElement) AppendChild(child *HtmlElement) {
+	e.elements = append(e.elements, child)
+}
+
+func (e *HtmlElement) findParent(target *HtmlElement) (*HtmlElement, int) {
+	for i, child := range e.elements {
+		if child == target {
+			return e, i
+		}
+		if parent, j := child.findParent(target); parent != nil {
+			return parent, j
+		}
+	}
+	return nil, -1
+}
+
+func (e *HtmlElement) Remove(target *HtmlElement) bool {
+	parent, i := e.findParent(target)
+	if parent == nil {
+		return false
+	}
+	parent.elements = append(parent.elements[:i], parent.elements[i+1:]...)
+	return true
+}
+
+func (e *HtmlElement) Replace(target, replacement *HtmlElement) bool {
+	parent, i := e.findParent(target)
+	if parent == nil {
+		return false
+	}
+	parent.elements[i] = replacement
+	return true
+}
+
+func (e *HtmlElement) InsertBefore(target, node *HtmlElement) bool {
+	return e.insertAt(target, node, 0)
+}
+
+func (e *HtmlElement) InsertAfter(target, node *HtmlElement) bool {
+	return e.insertAt(target, node, 1)
+}
+
+func (e *HtmlElement) insertAt(target, node *HtmlElement, offset int) bool {
+	parent, i := e.findParent(target)
+	if parent == nil {
+		return false
+	}
+	i += offset
+	parent.elements = append(parent.elements, nil)
+	copy(parent.elements[i+1:], parent.elements[i:])
+	parent.elements[i] = node
+	return true
+}
+
+// NewHtmlElement creates a detached element, ready to be inserted into a tree.
+func NewHtmlElement(name, text string) *HtmlElement {
+	return newHtmlElement(name, text)
+}
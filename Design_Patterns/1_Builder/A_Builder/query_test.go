@@ -0,0 +1,167 @@
+package firstexample
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const page = `<div id="page">
+  <nav class="menu main">
+    <ul>
+      <li class="item active"><a href="/">home</a></li>
+      <li class="item"><a href="/about">about</a></li>
+    </ul>
+  </nav>
+  <ul id="list">
+    <li>one</li>
+    <li class="item">two<span class="item">!</span></li>
+  </ul>
+</div>`
+
+func parsePage(t *testing.T) *HtmlElement {
+	t.Helper()
+	root, err := ParseHtml(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// describe tells the matched elements apart by their name and all the text inside them.
+func describe(elements []*HtmlElement) []string {
+	var text func(e *HtmlElement) string
+	text = func(e *HtmlElement) string {
+		s := e.text
+		for _, child := range e.elements {
+			s += text(child)
+		}
+		return s
+	}
+	var result []string
+	for _, e := range elements {
+		result = append(result, e.name+":"+text(e))
+	}
+	return result
+}
+
+func TestQueryAll(t *testing.T) {
+	root := parsePage(t)
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"li", []string{"li:home", "li:about", "li:one", "li:two!"}},
+		{"LI", []string{"li:home", "li:about", "li:one", "li:two!"}},
+		{"#list", []string{"ul:onetwo!"}},
+		{"div", []string{"div:homeaboutonetwo!"}}, // The root is a candidate too
+		{".item", []string{"li:home", "li:about", "li:two!", "span:!"}},
+		{"li.item.active", []string{"li:home"}},
+		{".active.item", []string{"li:home"}},
+		{"*.menu", []string{"nav:homeabout"}},
+		{"nav li", []string{"li:home", "li:about"}},
+		{"nav > li", nil}, // The li elements are grandchildren of the nav
+		{"nav > ul > li > a", []string{"a:home", "a:about"}},
+		{"#page > ul li", []string{"li:one", "li:two!"}},
+		{"div .item", []string{"li:home", "li:about", "li:two!", "span:!"}},
+		{"ul > .item", []string{"li:home", "li:about", "li:two!"}},
+		{"#list  >  li   span", []string{"span:!"}},
+		{"p", nil},
+		{"#nothing li", nil},
+	}
+	for _, tt := range tests {
+		found, err := root.QueryAll(tt.selector)
+		if err != nil {
+			t.Errorf("%q: %v", tt.selector, err)
+			continue
+		}
+		if got := describe(found); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("QueryAll(%q) = %q, want %q", tt.selector, got, tt.want)
+		}
+	}
+
+	first, err := root.Query(".item a")
+	if err != nil || first == nil || first.text != "home" {
+		t.Errorf("Query(.item a) = %v, %v", first, err)
+	}
+	if none, err := root.Query("table"); none != nil || err != nil {
+		t.Errorf("Query(table) = %v, %v, want nil, nil", none, err)
+	}
+}
+
+func TestInvalidSelectors(t *testing.T) {
+	for _, selector := range []string{"", "  ", "ul >", "> li", "#", "li.", "#a#b", "ul + li", "li,p", "a[href]"} {
+		if _, err := parsePage(t).QueryAll(selector); err == nil {
+			t.Errorf("QueryAll(%q) did not fail", selector)
+		}
+	}
+}
+
+func TestMutations(t *testing.T) {
+	root := parsePage(t)
+	list, _ := root.Query("#list")
+	one, _ := list.Query("li")
+	two, _ := list.Query("li.item")
+
+	zero := NewHtmlElement("li", "zero")
+	if !root.InsertBefore(one, zero) {
+		t.Fatal("InsertBefore did not find the first li")
+	}
+	three := NewHtmlElement("li", "")
+	if !root.InsertAfter(two, three) {
+		t.Fatal("InsertAfter did not find the last li")
+	}
+	if !root.Replace(one, NewHtmlElement("li", "ONE")) {
+		t.Fatal("Replace did not find the li")
+	}
+	if !root.Remove(zero) {
+		t.Fatal("Remove did not find the inserted li")
+	}
+	list.AppendChild(NewHtmlElement("li", "four"))
+	if err := two.SetAttr("class", "last"); err != nil {
+		t.Fatal(err)
+	}
+	two.RemoveAttr("missing")
+	three.SetText("three")
+
+	want := `<ul id="list"><li>ONE</li><li class="last">two<span class="item">!</span></li><li>three</li><li>four</li></ul>`
+	if got := compact(t, list); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// The first li of the page is in the nav, which still has both of its items
+	if found, _ := root.QueryAll("nav li"); len(found) != 2 {
+		t.Errorf("the nav has %d items after changing the other list", len(found))
+	}
+
+	// Nodes that are not in the tree are not found, and the tree is left alone
+	detached := NewHtmlElement("p", "")
+	if root.Remove(detached) || root.Replace(detached, zero) || root.InsertBefore(detached, zero) ||
+		root.InsertAfter(detached, zero) || root.Remove(root) {
+		t.Error("a mutation succeeded for a node that is not below the receiver")
+	}
+	if got := compact(t, list); got != want {
+		t.Errorf("failed mutations changed the tree: %s", got)
+	}
+}
+
+func TestAccessorsDoNotExposeTheTree(t *testing.T) {
+	root := parsePage(t)
+	list, _ := root.Query("#list")
+	if list.Name() != "ul" || len(list.Children()) != 2 {
+		t.Fatalf("Name() = %q, %d children", list.Name(), len(list.Children()))
+	}
+	children := list.Children()
+	children[0] = NewHtmlElement("li", "changed")
+	_ = append(children[:1], NewHtmlElement("li", "appended"))
+	if got := describe(list.Children()); !reflect.DeepEqual(got, []string{"li:one", "li:two!"}) {
+		t.Errorf("changing the slice from Children changed the tree: %q", got)
+	}
+	if id, ok := list.Attr("id"); !ok || id != "list" {
+		t.Errorf("Attr(id) = %q, %v", id, ok)
+	}
+	list.RemoveAttr("id")
+	if _, ok := list.Attr("id"); ok {
+		t.Error("RemoveAttr did not remove the id")
+	}
+}
@@ -0,0 +1,178 @@
+package firstexample
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+Building the whole document in nested string builders means every level copies the markup of all the levels below it,
+and the entire page has to sit in memory before it can be sent anywhere.
+Instead, the tree is walked once and every piece is written straight to an io.Writer, so a large page can be streamed
+to a file or an HTTP response as it is rendered.
+*/
+
+type RenderMode int
+
+const (
+	// Pretty puts every tag and text on its own line, indented by its depth in the tree.
+	Pretty RenderMode = iota
+	// Compact writes the markup without any whitespace between the tags.
+	Compact
+)
+
+const defaultIndent = 2
+
+type RenderOptions struct {
+	Mode RenderMode
+	// Indent is the number of spaces per nesting level in Pretty mode. Zero means the default of two spaces.
+	Indent int
+}
+
+// Same characters as html.EscapeString, but a replacer can write to the output without building a new string first.
+var htmlEscaper = strings.NewReplacer(
+	`&`, "&amp;",
+	`'`, "&#39;",
+	`<`, "&lt;",
+	`>`, "&gt;",
+	`"`, "&#34;",
+)
+
+/*
+There is no way to escape anything inside a comment, so text that would end it early is refused instead.
+That is "--" anywhere, since browsers also end comments at "--!>", a leading ">" or "->", which they read
+as an empty comment, and a trailing "-", which would run into the "-->" that closes it.
+*/
+
+var ErrInvalidComment = errors.New("invalid comment")
+
+func isValidComment(text string) bool {
+	return !strings.Contains(text, "--") && !strings.HasPrefix(text, ">") && !strings.HasPrefix(text, "->") &&
+		!strings.HasSuffix(text, "-")
+}
+
+// WriteTo renders the element in Pretty mode with the default indent. It makes HtmlElement an io.WriterTo.
+func (e *HtmlElement) WriteTo(w io.Writer) (int64, error) {
+	return e.Render(w, RenderOptions{})
+}
+
+// Render writes the element and all of its descendants to w, returning the number of bytes written.
+func (e *HtmlElement) Render(w io.Writer, opts RenderOptions) (int64, error) {
+	if opts.Indent <= 0 {
+		opts.Indent = defaultIndent
+	}
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	r := renderer{w: bw, opts: opts}
+	r.element(e, 0)
+	if r.err == nil {
+		r.err = bw.Flush()
+	}
+	return cw.n, r.err
+}
+
+// The renderer keeps the first error it gets, and every write after that does nothing.
+// Trees built by hand can still contain invalid names, so the renderer checks every name before writing it.
+type renderer struct {
+	w    *bufio.Writer
+	opts RenderOptions
+	err  error
+}
+
+func (r *renderer) write(s string) {
+	if r.err != nil {
+		return
+	}
+	_, r.err = r.w.WriteString(s)
+}
+
+func (r *renderer) name(what, s string) {
+	if r.err == nil {
+		r.err = checkName(what, s)
+	}
+	r.write(s)
+}
+
+func (r *renderer) escape(s string) {
+	if r.err != nil {
+		return
+	}
+	_, r.err = htmlEscaper.WriteString(r.w, s)
+}
+
+func (r *renderer) startLine(depth int) {
+	if r.opts.Mode != Pretty || r.err != nil {
+		return
+	}
+	for i := 0; i < depth*r.opts.Indent && r.err == nil; i++ {
+		r.err = r.w.WriteByte(' ')
+	}
+}
+
+func (r *renderer) endLine() {
+	if r.opts.Mode == Pretty {
+		r.write("\n")
+	}
+}
+
+func (r *renderer) element(e *HtmlElement, depth int) {
+	r.startLine(depth)
+	switch e.kind {
+	case textNode:
+		r.escape(e.text)
+		r.endLine()
+		return
+	case commentNode:
+		if r.err == nil && !isValidComment(e.text) {
+			r.err = fmt.Errorf("%w: %q", ErrInvalidComment, e.text)
+		}
+		r.write("<!--")
+		r.write(e.text)
+		r.write("-->")
+		r.endLine()
+		return
+	}
+	r.write("<")
+	r.name("tag name", e.name)
+	for _, a := range e.attributes {
+		r.write(" ")
+		r.name("attribute name", a.name)
+		r.write(`="`)
+		r.escape(a.value)
+		r.write(`"`)
+	}
+	r.write(">")
+	r.endLine()
+	if isVoidElement(e.name) {
+		return
+	}
+
+	if len(e.text) > 0 {
+		r.startLine(depth + 1)
+		r.escape(e.text)
+		r.endLine()
+	}
+	for _, el := range e.elements {
+		r.element(el, depth+1) // Recursion
+	}
+
+	r.startLine(depth)
+	r.write("</")
+	r.write(e.name)
+	r.write(">")
+	r.endLine()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
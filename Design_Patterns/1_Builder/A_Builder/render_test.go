@@ -0,0 +1,145 @@
+package firstexample
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// card has a bit of everything: attributes that need escaping, text, a void element and a comment.
+func card() *HtmlElement {
+	b := NewHtmlBuilder("div").Class("card")
+	b.Child("h1").Text("Fish & Chips").End().
+		Child("img").Attr("src", "/fish.png").Attr("alt", `"Fresh" fish`).End().
+		Child("p").Attr("data-user", "<script>").Text("<b>not bold</b>").End()
+	root := b.top().root
+	root.AppendChild(&HtmlElement{kind: commentNode, text: " end of card "})
+	return root
+}
+
+func TestRenderModes(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RenderOptions
+		want string
+	}{
+		{"compact", RenderOptions{Mode: Compact},
+			`<div class="card"><h1>Fish &amp; Chips</h1><img src="/fish.png" alt="&#34;Fresh&#34; fish">` +
+				`<p data-user="&lt;script&gt;">&lt;b&gt;not bold&lt;/b&gt;</p><!-- end of card --></div>`},
+		{"pretty with the default indent", RenderOptions{}, `<div class="card">
+  <h1>
+    Fish &amp; Chips
+  </h1>
+  <img src="/fish.png" alt="&#34;Fresh&#34; fish">
+  <p data-user="&lt;script&gt;">
+    &lt;b&gt;not bold&lt;/b&gt;
+  </p>
+  <!-- end of card -->
+</div>
+`},
+		{"pretty indented by four", RenderOptions{Mode: Pretty, Indent: 4}, `<div class="card">
+    <h1>
+        Fish &amp; Chips
+    </h1>
+    <img src="/fish.png" alt="&#34;Fresh&#34; fish">
+    <p data-user="&lt;script&gt;">
+        &lt;b&gt;not bold&lt;/b&gt;
+    </p>
+    <!-- end of card -->
+</div>
+`},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		n, err := card().Render(&sb, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("%s:\ngot\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+		if n != int64(sb.Len()) {
+			t.Errorf("%s: Render returned %d bytes, wrote %d", tt.name, n, sb.Len())
+		}
+	}
+}
+
+func TestSelfClosingTagsRenderAsVoidElements(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{`<p>a<br/>b</p>`, `<p>a<br>b</p>`},
+		{`<p><img src="x" /><IMG src="y"></p>`, `<p><img src="x"><img src="y"></p>`},
+		{`<div><span/></div>`, `<div><span></span></div>`}, // Not void, so it still needs an end tag
+	}
+	for _, tt := range tests {
+		e, err := ParseHtml(strings.NewReader(tt.input))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		var sb strings.Builder
+		if _, err := e.Render(&sb, RenderOptions{Mode: Compact}); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("%s rendered as %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRenderRefusesCommentsItCannotWrite(t *testing.T) {
+	tests := []struct {
+		text string
+		ok   bool
+	}{
+		{" fine ", true},
+		{"a - b", true},
+		{"", true},
+		{" a -- b ", false},
+		{"--> <script>alert(1)</script>", false},
+		{"x --!> y", false},
+		{">", false},
+		{"-> x", false},
+		{"x -", false},
+	}
+	for _, tt := range tests {
+		e := NewHtmlElement("div", "")
+		e.AppendChild(&HtmlElement{kind: commentNode, text: tt.text})
+		var sb strings.Builder
+		_, err := e.Render(&sb, RenderOptions{Mode: Compact})
+		if tt.ok && err != nil {
+			t.Errorf("comment %q: %v", tt.text, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidComment) {
+			t.Errorf("comment %q: got %v and %q, want ErrInvalidComment", tt.text, err, sb.String())
+		}
+	}
+}
+
+// failingWriter accepts a few bytes and then fails, like a connection that was closed.
+type failingWriter struct {
+	room int
+}
+
+var errClosed = errors.New("closed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.room {
+		n := w.room
+		w.room = 0
+		return n, errClosed
+	}
+	w.room -= len(p)
+	return len(p), nil
+}
+
+func TestRenderReportsWriteErrors(t *testing.T) {
+	e := NewHtmlElement("ul", "")
+	for i := 0; i < 1000; i++ {
+		e.AppendChild(NewHtmlElement("li", "item"))
+	}
+	n, err := e.Render(&failingWriter{room: 100}, RenderOptions{Mode: Compact})
+	if !errors.Is(err, errClosed) || n != 100 {
+		t.Errorf("Render() = %d, %v, want 100, %v", n, err, errClosed)
+	}
+}
@@ -14,6 +14,8 @@ type Person struct {
 	// job
 	CompanyName, Position string
 	AnnualIncome          int
+	// registered facets, see facets.go
+	Extras map[string]interface{}
 }
 
 type PersonBuilder struct {
@@ -88,8 +90,19 @@ func (b *PersonJobBuilder) Earning(annualIncome int) *PersonJobBuilder {
 
 //---------------------------------------------------------------------------------//
 
-func (b *PersonBuilder) Build() *Person {
-	return b.person
+// Build returns a copy of the person, so that using the builder afterwards does not change what was already built.
+func (b *PersonBuilder) Build() (*Person, error) {
+	if err := b.person.validate(); err != nil {
+		return nil, err
+	}
+	person := *b.person
+	if b.person.Extras != nil {
+		person.Extras = make(map[string]interface{}, len(b.person.Extras))
+		for k, v := range b.person.Extras {
+			person.Extras[k] = v
+		}
+	}
+	return &person, nil
 }
 
 //---------------------------------------------------------------------------------//
@@ -99,6 +112,10 @@ func main() {
 	pb.
 		Lives().At("123 London Road").In("London").WithPostcode("SW12BC").
 		Works().At("Fabrikam").AsA("Programmer").Earning(123000)
-	person := pb.Build()
+	person, err := pb.Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	fmt.Println(person)
 }
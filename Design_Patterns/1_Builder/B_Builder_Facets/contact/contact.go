@@ -0,0 +1,81 @@
+package contact
+
+import (
+	"fmt"
+	"strings"
+
+	builderfacets "github.com/Germanchrystan/design_patterns_course/Design_Patterns/1_Builder/B_Builder_Facets"
+)
+
+/*
+This package adds a contact details facet to the person builder without touching the builderfacets package.
+Importing it is enough for the facet to be registered.
+*/
+
+const FacetName = "contact"
+
+type Details struct {
+	Email, Phone string
+}
+
+type PersonContactBuilder struct {
+	builderfacets.PersonBuilder
+}
+
+func init() {
+	builderfacets.RegisterFacet(FacetName, builderfacets.Facet{
+		New: func(b builderfacets.PersonBuilder) interface{} {
+			return &PersonContactBuilder{b}
+		},
+		Validate: func(p *builderfacets.Person) error {
+			if d := Of(p); d.Email != "" && !strings.Contains(d.Email, "@") {
+				return fmt.Errorf("email %q should contain @", d.Email)
+			}
+			return nil
+		},
+	})
+}
+
+// Contacts is a shortcut for the type assertion, so the facet can be reached from any other facet.
+func Contacts(b *builderfacets.PersonBuilder) *PersonContactBuilder {
+	return b.Facet(FacetName).(*PersonContactBuilder)
+}
+
+// Of reads the contact details back from a person.
+func Of(p *builderfacets.Person) Details {
+	d, _ := p.Extras[FacetName].(Details)
+	return d
+}
+
+// Details are stored by value, so every change reads them, updates the copy and stores it again.
+func (b *PersonContactBuilder) update(change func(d *Details)) *PersonContactBuilder {
+	p := b.Target()
+	d := Of(p)
+	change(&d)
+	p.SetExtra(FacetName, d)
+	return b
+}
+
+func (b *PersonContactBuilder) Email(email string) *PersonContactBuilder {
+	return b.update(func(d *Details) { d.Email = email })
+}
+
+func (b *PersonContactBuilder) Phone(phone string) *PersonContactBuilder {
+	return b.update(func(d *Details) { d.Phone = phone })
+}
+
+func main() {
+	pb := builderfacets.NewPersonBuilder()
+	pb.
+		Lives().At("123 London Road").In("London").
+		Facet(FacetName).(*PersonContactBuilder).Email("john@fabrikam.com").Phone("+44 20 0000 0000").
+		Works().At("Fabrikam")
+	Contacts(pb).Email("john.smith@fabrikam.com")
+
+	person, err := pb.Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(Of(person))
+}
@@ -0,0 +1,97 @@
+package builderfacets
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+/*
+Address and job are not the only aspects a person can have. Rather than adding a new builder to this file every time,
+other packages can register facets of their own, usually from an init function.
+
+A facet builder embeds PersonBuilder, exactly like PersonAddressBuilder and PersonJobBuilder do. That way it shares
+the same *Person, and Lives, Works and Facet are still available on it, so the fluent chain can keep going from there.
+Whatever the facet wants to remember goes into Person.Extras under its own key.
+*/
+
+type Facet struct {
+	// New wraps a copy of the builder, just like Lives and Works do, and returns the facet builder.
+	New func(b PersonBuilder) interface{}
+	// Validate is optional and is called by Build along with the checks of the built-in facets.
+	Validate func(p *Person) error
+}
+
+var (
+	facetsMu sync.RWMutex
+	facets   = map[string]Facet{}
+)
+
+// RegisterFacet makes a facet available under the given name. Registering the same name twice is a programming error and panics.
+func RegisterFacet(name string, facet Facet) {
+	facetsMu.Lock()
+	defer facetsMu.Unlock()
+	if facet.New == nil {
+		panic("builderfacets: RegisterFacet " + name + " without a New function")
+	}
+	if _, exists := facets[name]; exists {
+		panic("builderfacets: RegisterFacet called twice for facet " + name)
+	}
+	facets[name] = facet
+}
+
+// Facets lists the names of all the registered facets, sorted.
+func Facets() []string {
+	facetsMu.RLock()
+	defer facetsMu.RUnlock()
+	return sortedFacetNames()
+}
+
+// Facet switches to a registered facet builder. The caller asserts the type the facet package documents.
+// Asking for a facet that was never registered panics, as the registering package was simply not imported.
+func (b *PersonBuilder) Facet(name string) interface{} {
+	facetsMu.RLock()
+	facet, ok := facets[name]
+	facetsMu.RUnlock()
+	if !ok {
+		panic(fmt.Sprintf("builderfacets: unknown facet %q (forgotten import?)", name))
+	}
+	return facet.New(*b)
+}
+
+// Target gives facet builders in other packages access to the person being built.
+func (b *PersonBuilder) Target() *Person {
+	return b.person
+}
+
+// SetExtra stores a value in the person for a facet. Values should not be pointers, so that Build can copy them.
+func (p *Person) SetExtra(key string, value interface{}) {
+	if p.Extras == nil {
+		p.Extras = map[string]interface{}{}
+	}
+	p.Extras[key] = value
+}
+
+func validateFacets(p *Person) []*FacetError {
+	facetsMu.RLock()
+	defer facetsMu.RUnlock()
+	var problems []*FacetError
+	for _, name := range sortedFacetNames() {
+		if validate := facets[name].Validate; validate != nil {
+			if err := validate(p); err != nil {
+				problems = append(problems, &FacetError{name, err})
+			}
+		}
+	}
+	return problems
+}
+
+// Called with facetsMu held, so the validators always run in the same order.
+func sortedFacetNames() []string {
+	names := make([]string, 0, len(facets))
+	for name := range facets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,135 @@
+package builderfacets
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/*
+The facets only ever set fields, so a builder can easily end up describing a person that makes no sense.
+Build checks every facet before handing out the result, and instead of stopping at the first problem
+it collects all of them, so a bad record can be rejected with a single, complete error.
+*/
+
+var (
+	ErrInvalidPostcode = errors.New("invalid postcode")
+	ErrMissingCity     = errors.New("street address without a city")
+	ErrNegativeIncome  = errors.New("negative annual income")
+)
+
+// Letters and digits, optionally split by a single space or hyphen, such as "SW12BC", "SW1 2BC" or "1000-001".
+var postcodePattern = regexp.MustCompile(`^[A-Za-z0-9]{2,5}([ -]?[A-Za-z0-9]{2,5})?$`)
+
+// FieldError says which field of the person failed which check.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FacetError is a problem reported by the Validate function of a registered facet.
+type FacetError struct {
+	Facet string
+	Err   error
+}
+
+func (e *FacetError) Error() string {
+	return fmt.Sprintf("facet %s: %v", e.Facet, e.Err)
+}
+
+func (e *FacetError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is what Build returns for a person that makes no sense. The built-in checks are about a single
+// field each, while a registered facet can only say that it does not accept the person, so they are kept apart.
+type ValidationError struct {
+	Fields []*FieldError
+	Facets []*FacetError
+}
+
+func (e *ValidationError) Error() string {
+	var messages []string
+	for _, p := range e.Unwrap() {
+		messages = append(messages, p.Error())
+	}
+	return "invalid person: " + strings.Join(messages, "; ")
+}
+
+// Field returns the problem with the named field of Person, or nil when there is none.
+func (e *ValidationError) Field(name string) *FieldError {
+	for _, f := range e.Fields {
+		if f.Field == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Unwrap lists the field problems first, in the order Build checks them, and then the facet problems by facet name.
+func (e *ValidationError) Unwrap() []error {
+	problems := make([]error, 0, len(e.Fields)+len(e.Facets))
+	for _, f := range e.Fields {
+		problems = append(problems, f)
+	}
+	for _, f := range e.Facets {
+		problems = append(problems, f)
+	}
+	return problems
+}
+
+// The errors package of Go 1.18, which the module declares, does not call Unwrap() []error,
+// so errors.Is and errors.As reach the sentinel errors of the fields through these two methods instead.
+
+func (e *ValidationError) Is(target error) bool {
+	for _, p := range e.Unwrap() {
+		if errors.Is(p, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *ValidationError) As(target interface{}) bool {
+	for _, p := range e.Unwrap() {
+		if errors.As(p, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Person) validate() error {
+	var fields []*FieldError
+	add := func(field string, err error) {
+		fields = append(fields, &FieldError{field, err})
+	}
+
+	// address
+	if p.Postcode != "" && !postcodePattern.MatchString(p.Postcode) {
+		add("Postcode", fmt.Errorf("%w %q", ErrInvalidPostcode, p.Postcode))
+	}
+	if p.StreetAddress != "" && p.City == "" {
+		add("City", ErrMissingCity)
+	}
+	// job
+	if p.AnnualIncome < 0 {
+		add("AnnualIncome", fmt.Errorf("%w %d", ErrNegativeIncome, p.AnnualIncome))
+	}
+
+	facetProblems := validateFacets(p)
+
+	if len(fields) > 0 || len(facetProblems) > 0 {
+		return &ValidationError{fields, facetProblems}
+	}
+	return nil
+}
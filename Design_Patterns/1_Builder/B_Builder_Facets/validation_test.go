@@ -0,0 +1,108 @@
+package builderfacets
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var errNegativeAge = errors.New("negative age")
+
+// The facets of other packages cannot be imported here, so the tests register a small one of their own.
+func init() {
+	RegisterFacet("testage", Facet{
+		New: func(b PersonBuilder) interface{} { return &b },
+		Validate: func(p *Person) error {
+			if age, ok := p.Extras["testage"].(int); ok && age < 0 {
+				return errNegativeAge
+			}
+			return nil
+		},
+	})
+}
+
+func TestBuildRejectsEachProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		build  func(b *PersonBuilder)
+		fields []string
+		facets []string
+		is     error
+	}{
+		{"postcode", func(b *PersonBuilder) { b.Lives().At("1 Main St").In("Leeds").WithPostcode("LS1 4AP!") },
+			[]string{"Postcode"}, nil, ErrInvalidPostcode},
+		{"postcode with two spaces", func(b *PersonBuilder) { b.Lives().WithPostcode("SW1  2BC") },
+			[]string{"Postcode"}, nil, ErrInvalidPostcode},
+		{"street without a city", func(b *PersonBuilder) { b.Lives().At("1 Main St") },
+			[]string{"City"}, nil, ErrMissingCity},
+		{"negative income", func(b *PersonBuilder) { b.Works().At("Acme").Earning(-1) },
+			[]string{"AnnualIncome"}, nil, ErrNegativeIncome},
+		{"registered facet", func(b *PersonBuilder) { b.Target().SetExtra("testage", -3) },
+			nil, []string{"testage"}, errNegativeAge},
+		{"everything at once", func(b *PersonBuilder) {
+			b.Lives().At("1 Main St").WithPostcode("?").Works().Earning(-5)
+			b.Target().SetExtra("testage", -1)
+		}, []string{"Postcode", "City", "AnnualIncome"}, []string{"testage"}, errNegativeAge},
+	}
+	for _, tt := range tests {
+		b := NewPersonBuilder()
+		tt.build(b)
+		person, err := b.Build()
+
+		var v *ValidationError
+		if !errors.As(err, &v) || person != nil {
+			t.Errorf("%s: Build() = %v, %v, want a *ValidationError", tt.name, person, err)
+			continue
+		}
+		var fields, facets []string
+		for _, f := range v.Fields {
+			fields = append(fields, f.Field)
+		}
+		for _, f := range v.Facets {
+			facets = append(facets, f.Facet)
+		}
+		if !reflect.DeepEqual(fields, tt.fields) || !reflect.DeepEqual(facets, tt.facets) {
+			t.Errorf("%s: fields %q and facets %q, want %q and %q", tt.name, fields, facets, tt.fields, tt.facets)
+		}
+		// Calling Is directly checks the method itself, whatever the errors package of the toolchain does
+		if !errors.Is(err, tt.is) || !v.Is(tt.is) {
+			t.Errorf("%s: %v does not match %v", tt.name, err, tt.is)
+		}
+	}
+}
+
+func TestValidationErrorFindsFields(t *testing.T) {
+	_, err := NewPersonBuilder().Lives().At("1 Main St").WithPostcode("no!").Build()
+	var v *ValidationError
+	if !errors.As(err, &v) {
+		t.Fatalf("Build() = %v, want a *ValidationError", err)
+	}
+	if f := v.Field("Postcode"); f == nil || !errors.Is(f, ErrInvalidPostcode) {
+		t.Errorf("Field(Postcode) = %v", f)
+	}
+	if f := v.Field("AnnualIncome"); f != nil {
+		t.Errorf("Field(AnnualIncome) = %v, want nil", f)
+	}
+	var field *FieldError
+	if !v.As(&field) || field.Field != "Postcode" {
+		t.Errorf("As found %v, want the Postcode FieldError first", field)
+	}
+	if v.Is(ErrNegativeIncome) || errors.Is(err, errNegativeAge) {
+		t.Error("matched a problem the person does not have")
+	}
+	want := `invalid person: Postcode: invalid postcode "no!"; City: street address without a city`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err, want)
+	}
+}
+
+func TestBuildAcceptsAValidPerson(t *testing.T) {
+	for _, postcode := range []string{"", "SW12BC", "SW1 2BC", "1000-001", "10115"} {
+		b := NewPersonBuilder()
+		b.Lives().At("1 Main St").In("Leeds").WithPostcode(postcode).Works().At("Acme").Earning(0)
+		b.Target().SetExtra("testage", 40)
+		if _, err := b.Build(); err != nil {
+			t.Errorf("postcode %q: %v", postcode, err)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package builderparameter
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+)
 
 /*
 One question that might be asked is how do you get the uses of the API to actually use the builders,
@@ -9,43 +14,80 @@ One approach is to simply hide the objects that you want your users not to touch
 */
 
 type email struct {
-	from, to, subject, body string
+	from        *mail.Address
+	to, cc, bcc []*mail.Address
+	subject     string
+	// A message can have a plain text body, an HTML body or both.
+	body, htmlBody string
+	attachments    []attachment
 }
 
 type EmailBuilder struct {
 	email email
+	// Problems with the input are collected here instead of panicking, see validation.go
+	errs    []*HeaderError
+	fromSet bool
 }
 
 func (b *EmailBuilder) From(from string) *EmailBuilder {
-	if !strings.Contains(from, "@") {
-		panic("from email should contain @")
-	}
+	b.fromSet = true
+	b.email.from = b.parseAddress("from", from)
+	return b
+}
 
-	b.email.from = from
+// To adds recipients, so it can be called with several addresses or several times.
+func (b *EmailBuilder) To(to ...string) *EmailBuilder {
+	b.email.to = append(b.email.to, b.parseAddresses("to", to)...)
 	return b
 }
 
-func (b *EmailBuilder) To(to string) *EmailBuilder {
-	if !strings.Contains(to, "@") {
-		panic("destination email should contain @")
-	}
+func (b *EmailBuilder) Cc(cc ...string) *EmailBuilder {
+	b.email.cc = append(b.email.cc, b.parseAddresses("cc", cc)...)
+	return b
+}
 
-	b.email.to = to
+func (b *EmailBuilder) Bcc(bcc ...string) *EmailBuilder {
+	b.email.bcc = append(b.email.bcc, b.parseAddresses("bcc", bcc)...)
 	return b
 }
 
 func (b *EmailBuilder) Subject(subject string) *EmailBuilder {
+	b.validateSubject(subject)
 	b.email.subject = subject
 	return b
 }
 
+// Body sets the plain text body.
 func (b *EmailBuilder) Body(body string) *EmailBuilder {
 	b.email.body = body
 	return b
 }
 
-func sendMailImpl(email *email) {
-	// ...
+func (b *EmailBuilder) HTMLBody(html string) *EmailBuilder {
+	b.email.htmlBody = html
+	return b
+}
+
+// Attach adds a file to the message. An empty contentType is guessed from the extension of the filename.
+func (b *EmailBuilder) Attach(filename, contentType string, data []byte) *EmailBuilder {
+	b.email.attachments = append(b.email.attachments, attachment{filename, contentType, data})
+	return b
+}
+
+var ErrNoTransport = errors.New("no transport configured for sending email")
+
+// DefaultTransport is what SendEmail delivers through. It has to be set before any email can be sent.
+var DefaultTransport Transport
+
+func sendMailImpl(transport Transport, email *email) error {
+	if transport == nil {
+		return ErrNoTransport
+	}
+	message, err := email.message(time.Now())
+	if err != nil {
+		return fmt.Errorf("building email: %w", err)
+	}
+	return transport.Send(email.from.Address, email.recipients(), message)
 }
 
 /*
@@ -63,25 +105,41 @@ type build func(*EmailBuilder)
 // It takes an argument action of type build.
 // What happens is that whenever somebody calls  the function, they have to provide the body of a function,
 // which takes an email builder as the first and only parameter and doesn't return any values
-func SendEmail(action build) {
+func SendEmail(action build) error {
+	return SendEmailWith(DefaultTransport, action)
+}
+
+// SendEmailWith does the same as SendEmail, but delivers through the given transport.
+func SendEmailWith(transport Transport, action build) error {
 	// Initializing the builder
 	builder := EmailBuilder{}
 	// Builder pointer is passed as an argument for the action.
 	action(&builder)
+	// Nothing is sent unless the whole email is valid
+	if err := builder.validate(); err != nil {
+		return err
+	}
 	// Then we would do the internal functioning of the sendMailImpl
-	sendMailImpl(&builder.email)
+	return sendMailImpl(transport, &builder.email)
 }
 
 func main() {
 	// From the client's perspective, they would have to call the SendEmail function.
 	//
-	SendEmail(func(b *EmailBuilder) {
+	DefaultTransport = &FileTransport{Dir: "outbox"}
+	err := SendEmail(func(b *EmailBuilder) {
 		b.
 			From("foo@bar.com").
-			To("bar@baz.com").
+			To("bar@baz.com", "qux@baz.com").
+			Cc("boss@bar.com").
 			Subject("Meeting").
-			Body("Hello, do you want to meet?")
+			Body("Hello, do you want to meet?").
+			HTMLBody("<p>Hello, do you want to <b>meet</b>?</p>").
+			Attach("agenda.txt", "", []byte("1. Coffee"))
 	})
+	if err != nil {
+		fmt.Println(err)
+	}
 	/*
 	 So, what happens is that when we call this function we create a builder, whichs is an email builder.
 	 Then, we apply the action, which is the entire body of the SendEmail function.
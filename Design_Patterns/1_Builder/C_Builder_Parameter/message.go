@@ -0,0 +1,211 @@
+package builderparameter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+The email is turned into an RFC 5322 message with MIME parts:
+  - a text or an HTML body on its own is a single part,
+  - text and HTML together become multipart/alternative, so that clients pick the richest one they can show,
+  - attachments wrap all of that in multipart/mixed.
+*/
+
+type attachment struct {
+	filename, contentType string
+	data                  []byte
+}
+
+// A part knows its headers before it is written, which is why multipart boundaries are picked up front.
+type mimePart struct {
+	header textproto.MIMEHeader
+	write  func(w io.Writer) error
+}
+
+func (e *email) message(now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", e.from.String())
+	if len(e.to) > 0 {
+		writeHeader(&buf, "To", formatAddressList(e.to))
+	}
+	if len(e.cc) > 0 {
+		writeHeader(&buf, "Cc", formatAddressList(e.cc))
+	}
+	// Bcc recipients are only part of the envelope, otherwise every recipient would see them.
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", e.subject))
+	writeHeader(&buf, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", "<"+randomHex(16)+"@"+domainOf(e.from.Address)+">")
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	part := e.rootPart()
+	writeMIMEHeader(&buf, part.header)
+	buf.WriteString("\r\n")
+	if err := part.write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// recipients is the envelope: everybody who gets the message, Bcc included.
+func (e *email) recipients() []string {
+	var all []string
+	for _, list := range [][]*mail.Address{e.to, e.cc, e.bcc} {
+		for _, a := range list {
+			all = append(all, a.Address)
+		}
+	}
+	return all
+}
+
+// mail.Address.String quotes and encodes display names as needed, so non-ASCII names are safe in headers.
+func formatAddressList(addresses []*mail.Address) string {
+	formatted := make([]string, len(addresses))
+	for i, a := range addresses {
+		formatted[i] = a.String()
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func (e *email) rootPart() mimePart {
+	var body mimePart
+	switch {
+	case e.htmlBody != "" && e.body != "":
+		body = multipartPart("alternative",
+			textPart("text/plain", e.body),
+			textPart("text/html", e.htmlBody))
+	case e.htmlBody != "":
+		body = textPart("text/html", e.htmlBody)
+	default:
+		body = textPart("text/plain", e.body)
+	}
+	if len(e.attachments) == 0 {
+		return body
+	}
+
+	parts := []mimePart{body}
+	for _, a := range e.attachments {
+		parts = append(parts, attachmentPart(a))
+	}
+	return multipartPart("mixed", parts...)
+}
+
+func textPart(contentType, text string) mimePart {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=utf-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return mimePart{header, func(w io.Writer) error {
+		qp := quotedprintable.NewWriter(w)
+		// Quoted-printable keeps line breaks as they are, so they are normalised to CRLF first.
+		text := strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+		if _, err := qp.Write([]byte(text)); err != nil {
+			return err
+		}
+		return qp.Close()
+	}}
+}
+
+func attachmentPart(a attachment) mimePart {
+	contentType := a.contentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(a.filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.filename}))
+	return mimePart{header, func(w io.Writer) error {
+		encoded := base64.StdEncoding.EncodeToString(a.data)
+		// Lines of a message must not be longer than 78 characters, so the base64 text is wrapped at 76.
+		for len(encoded) > 76 {
+			if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+				return err
+			}
+			encoded = encoded[76:]
+		}
+		_, err := io.WriteString(w, encoded+"\r\n")
+		return err
+	}}
+}
+
+func multipartPart(subtype string, parts ...mimePart) mimePart {
+	boundary := "=_" + randomHex(12)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": boundary}))
+	return mimePart{header, func(w io.Writer) error {
+		mw := multipart.NewWriter(w)
+		if err := mw.SetBoundary(boundary); err != nil {
+			return err
+		}
+		for _, p := range parts {
+			pw, err := mw.CreatePart(p.header)
+			if err != nil {
+				return err
+			}
+			if err := p.write(pw); err != nil {
+				return err
+			}
+		}
+		return mw.Close()
+	}}
+}
+
+// Lines of a message should not be longer than 78 characters.
+const maxLineLength = 78
+
+/*
+Long header values are folded: a line break is put in front of one of their spaces, which then starts the next line.
+The value is not changed, so long lists of addresses and subjects stay the same once they are unfolded.
+Folding never splits an encoded word, because mime.QEncoding already splits the text into words of at most
+75 characters. Such a word does not fit after "Subject: ", so the value can also start on a line of its own.
+Only a single word longer than a line, which has no space to fold at, is written as it is.
+*/
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString(":")
+	n := len(key) + 1
+	for i, word := range strings.Split(value, " ") {
+		if word != "" && n+1+len(word) > maxLineLength && (i > 0 || 1+len(word) <= maxLineLength) {
+			buf.WriteString("\r\n")
+			n = 0
+		}
+		buf.WriteString(" ")
+		buf.WriteString(word)
+		n += 1 + len(word)
+	}
+	buf.WriteString("\r\n")
+}
+
+// Headers are written in a fixed order, so that the same email always produces the same message layout.
+func writeMIMEHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			writeHeader(buf, k, v)
+		}
+	}
+}
+
+func domainOf(address string) string {
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		return address[at+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,151 @@
+package builderparameter
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// With a seeded random source and a fixed time, the same email always becomes the same bytes.
+// The attachment types are guessed from extensions Go knows itself, so the result does not depend on the system.
+func TestMessageLayout(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b *EmailBuilder)
+	}{
+		{"text", func(b *EmailBuilder) {
+			b.Body("Hello,\nsee you at 10.")
+		}},
+		{"html", func(b *EmailBuilder) {
+			b.HTMLBody("<p>Hello</p>")
+		}},
+		{"alternative", func(b *EmailBuilder) {
+			b.Body("Hello").HTMLBody("<p>Hello</p>")
+		}},
+		{"long_headers", func(b *EmailBuilder) {
+			b.To(manyRecipients...).Subject(longSubject).Body("Hello")
+		}},
+		{"mixed", func(b *EmailBuilder) {
+			b.Body("Hello").HTMLBody("<p>Hello</p>").
+				Attach("agenda.html", "", []byte("<ol><li>Coffee</li></ol>")).
+				Attach("logo.bin", "", bytes.Repeat([]byte{0xff, 0x00}, 60))
+		}},
+	}
+	defer func(r io.Reader) { randomSource = r }(randomSource)
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			randomSource = rand.New(rand.NewSource(1))
+			b := &EmailBuilder{}
+			b.From("Jane Doé <jane@example.com>").To("bob@example.com").Cc("carol@example.com").Subject("Héllo")
+			tt.build(b)
+			if err := b.validate(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := b.email.message(now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".eml")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("message does not match %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+var (
+	longSubject    = "Réunion trimestrielle : résultats, prévisions et questions ouvertes — ordre du jour détaillé à lire avant jeudi ✓"
+	manyRecipients = func() []string {
+		var list []string
+		for i := 0; i < 12; i++ {
+			list = append(list, fmt.Sprintf("Équipe %d <team%d@example.com>", i, i))
+		}
+		return list
+	}()
+)
+
+func TestLongHeadersAreFolded(t *testing.T) {
+	b := &EmailBuilder{}
+	b.From("jane@example.com").To(manyRecipients...).Subject(longSubject).Body("Hello")
+	if err := b.validate(); err != nil {
+		t.Fatal(err)
+	}
+	message, err := b.email.message(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, _, _ := strings.Cut(string(message), "\r\n\r\n")
+	for _, line := range strings.Split(header, "\r\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("line of %d characters: %q", len(line), line)
+		}
+	}
+	for _, word := range strings.Fields(header) {
+		if strings.HasPrefix(word, "=?") && len(strings.TrimRight(word, ",")) > 75 {
+			t.Errorf("encoded word of %d characters: %q", len(word), word)
+		}
+	}
+
+	// Unfolding gives back exactly what went in
+	parsed, err := mail.ReadMessage(bufio.NewReader(bytes.NewReader(message)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != longSubject {
+		t.Errorf("Subject = %q, %v, want %q", subject, err, longSubject)
+	}
+	to, err := parsed.Header.AddressList("To")
+	if err != nil || len(to) != len(manyRecipients) {
+		t.Fatalf("To = %v, %v", to, err)
+	}
+	for i, a := range to {
+		if want := fmt.Sprintf("Équipe %d", i); a.Name != want {
+			t.Errorf("recipient %d is called %q, want %q", i, a.Name, want)
+		}
+	}
+}
+
+func TestWriteHeaderFoldsOnlyAtSpaces(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"short", "X-Test: short\r\n"},
+		{strings.Repeat("a", 100), "X-Test: " + strings.Repeat("a", 100) + "\r\n"}, // Nowhere to fold
+		{strings.Repeat("word ", 15) + "end", "X-Test: " + strings.Repeat("word ", 13) + "word\r\n word end\r\n"},
+		{"two  spaces", "X-Test: two  spaces\r\n"},
+		{strings.Repeat("b", 75), "X-Test:\r\n " + strings.Repeat("b", 75) + "\r\n"}, // Fits on a line of its own
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		writeHeader(&buf, "X-Test", tt.value)
+		if buf.String() != tt.want {
+			t.Errorf("writeHeader(%q) =\n%q, want\n%q", tt.value, buf.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package builderparameter
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+/*
+Until now sendMailImpl did nothing. Delivering the message is now the job of a Transport, so the same builder can
+talk to a mail server in production, drop files into a directory on a developer machine, or keep everything in
+memory during tests.
+
+A transport gets the envelope (who the message is from and every recipient, including the Bcc ones) and the
+finished RFC 5322 message, so it never has to know anything about the builder.
+*/
+
+type Transport interface {
+	Send(from string, recipients []string, message []byte) error
+}
+
+//---------------------------------------------------------------------------------//
+
+// SMTPTransport delivers through an SMTP server. The server is upgraded to TLS with STARTTLS whenever it offers it.
+type SMTPTransport struct {
+	Addr string // host:port
+	Auth smtp.Auth
+}
+
+func (t *SMTPTransport) Send(from string, recipients []string, message []byte) error {
+	if err := smtp.SendMail(t.Addr, t.Auth, from, recipients, message); err != nil {
+		return fmt.Errorf("smtp %s: %w", t.Addr, err)
+	}
+	return nil
+}
+
+//---------------------------------------------------------------------------------//
+
+// FileTransport writes every message as a .eml file into Dir, which most mail clients can open.
+type FileTransport struct {
+	Dir string
+}
+
+func (t *FileTransport) Send(from string, recipients []string, message []byte) error {
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return err
+	}
+	// The file is written under a temporary name first, so nobody watching the directory sees half a message.
+	tmp, err := os.CreateTemp(t.Dir, ".email-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(message); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), randomHex(4))
+	return os.Rename(tmp.Name(), filepath.Join(t.Dir, name))
+}
+
+//---------------------------------------------------------------------------------//
+
+type SentMessage struct {
+	From       string
+	Recipients []string
+	Data       []byte
+}
+
+// MemoryTransport keeps the messages it is given, which makes it the transport to use in tests.
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []SentMessage
+}
+
+func (t *MemoryTransport) Send(from string, recipients []string, message []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, SentMessage{
+		From:       from,
+		Recipients: append([]string{}, recipients...),
+		Data:       append([]byte{}, message...),
+	})
+	return nil
+}
+
+// Messages returns a copy of everything sent so far.
+func (t *MemoryTransport) Messages() []SentMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]SentMessage{}, t.messages...)
+}
+
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = nil
+}
+
+// randomSource supplies the Message-ID and the multipart boundaries. Tests replace it to get the same bytes every time.
+var randomSource io.Reader = rand.Reader
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(randomSource, b); err != nil {
+		panic(err) // crypto/rand does not fail on supported platforms
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,183 @@
+package builderparameter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeSMTPServer speaks just enough SMTP for net/smtp.SendMail: no STARTTLS and no AUTH are offered.
+// Every message it accepts is sent to received once the client has quit.
+type fakeSMTPServer struct {
+	listener net.Listener
+	received chan SentMessage
+	// rejectRecipient makes RCPT TO fail for that address, like a server that does not know the mailbox.
+	rejectRecipient string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{listener: l, received: make(chan SentMessage, 1)}
+	t.Cleanup(func() { l.Close() })
+	go s.serve(t)
+	return s
+}
+
+func (s *fakeSMTPServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeSMTPServer) serve(t *testing.T) {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return // Closed by the cleanup
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	var msg SentMessage
+	reply("220 localhost fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg.From = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			rcpt := strings.Trim(line[len("RCPT TO:"):], "<>")
+			if rcpt == s.rejectRecipient {
+				reply("550 no such mailbox")
+				continue
+			}
+			msg.Recipients = append(msg.Recipients, rcpt)
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var data bytes.Buffer
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, ".")) // Undo the dot stuffing
+			}
+			msg.Data = data.Bytes()
+			reply("250 queued")
+		case cmd == "RSET", cmd == "NOOP":
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			if msg.Data != nil {
+				s.received <- msg
+			}
+			return
+		default:
+			t.Errorf("fake SMTP server: unexpected command %q", line)
+			reply("500 unknown command")
+		}
+	}
+}
+
+// The line starting with a dot checks that the transport stuffs it, and that the server gets it back unchanged.
+var testMessage = []byte("Subject: hi\r\n\r\nfirst line\r\n.leading dot\r\nlast line\r\n")
+
+func TestSMTPTransportSendsExactBytes(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	transport := &SMTPTransport{Addr: server.addr()}
+	recipients := []string{"a@example.com", "b@example.com"}
+	if err := transport.Send("me@example.com", recipients, testMessage); err != nil {
+		t.Fatal(err)
+	}
+	got := <-server.received
+	want := SentMessage{From: "me@example.com", Recipients: recipients, Data: testMessage}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("server received %+v\nwant %+v", got, want)
+	}
+}
+
+func TestSMTPTransportReportsRejectedRecipient(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.rejectRecipient = "nobody@example.com"
+	err := (&SMTPTransport{Addr: server.addr()}).Send("me@example.com", []string{"nobody@example.com"}, testMessage)
+	if err == nil || !strings.Contains(err.Error(), "550") || !strings.Contains(err.Error(), server.addr()) {
+		t.Fatalf("Send() = %v, want the 550 reply and the server address", err)
+	}
+}
+
+func TestSendEmailThroughSMTP(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	err := SendEmailWith(&SMTPTransport{Addr: server.addr()}, func(b *EmailBuilder) {
+		b.From("Me <me@example.com>").To("you@example.com").Bcc("secret@example.com").Subject("Hi").Body("Hello")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := <-server.received
+	if want := []string{"you@example.com", "secret@example.com"}; !reflect.DeepEqual(got.Recipients, want) {
+		t.Errorf("envelope recipients = %v, want %v", got.Recipients, want)
+	}
+	if bytes.Contains(got.Data, []byte("secret@example.com")) {
+		t.Error("the Bcc recipient is visible in the message")
+	}
+}
+
+func TestFileTransportWritesOneFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	if err := (&FileTransport{Dir: dir}).Send("me@example.com", []string{"you@example.com"}, testMessage); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || filepath.Ext(entries[0].Name()) != ".eml" {
+		t.Fatalf("outbox has %v, want a single .eml file", entries)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, testMessage) {
+		t.Fatalf("file has %q, want %q", data, testMessage)
+	}
+}
+
+func TestMemoryTransportKeepsCopies(t *testing.T) {
+	transport := &MemoryTransport{}
+	recipients := []string{"you@example.com"}
+	message := append([]byte{}, testMessage...)
+	if err := transport.Send("me@example.com", recipients, message); err != nil {
+		t.Fatal(err)
+	}
+	recipients[0], message[0] = "changed", 'X'
+
+	want := []SentMessage{{From: "me@example.com", Recipients: []string{"you@example.com"}, Data: testMessage}}
+	if got := transport.Messages(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Messages() = %+v, want %+v", got, want)
+	}
+	transport.Reset()
+	if got := transport.Messages(); len(got) != 0 {
+		t.Fatalf("Messages() after Reset = %+v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package builderparameter
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+Panicking on a bad address takes the whole request handler down with it. Instead, every builder method records
+what is wrong with its input and keeps going, and SendEmail returns all the problems together without sending anything.
+*/
+
+// MaxSubjectLength is counted in characters, not bytes.
+const MaxSubjectLength = 255
+
+var (
+	ErrInvalidAddress  = errors.New("invalid email address")
+	ErrHeaderInjection = errors.New("line break in header value")
+	ErrSubjectTooLong  = errors.New("subject too long")
+	ErrMissingFrom     = errors.New("email has no sender")
+	ErrNoRecipients    = errors.New("email has no recipients")
+)
+
+// HeaderError is a problem with a value given for one header, such as an invalid address passed to Cc.
+type HeaderError struct {
+	Header string
+	Err    error
+}
+
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Header, e.Err)
+}
+
+func (e *HeaderError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is what SendEmail returns instead of sending. A value can be wrong as soon as it is given,
+// but whether a sender or a recipient is missing is only known once the whole email has been built.
+type ValidationError struct {
+	Headers []*HeaderError
+	// Missing holds ErrMissingFrom and ErrNoRecipients.
+	Missing []error
+}
+
+func (e *ValidationError) Error() string {
+	var messages []string
+	for _, p := range e.Unwrap() {
+		messages = append(messages, p.Error())
+	}
+	return "invalid email: " + strings.Join(messages, "; ")
+}
+
+// Unwrap lists the header problems in the order the builder methods were called, and then what is missing.
+func (e *ValidationError) Unwrap() []error {
+	problems := make([]error, 0, len(e.Headers)+len(e.Missing))
+	for _, h := range e.Headers {
+		problems = append(problems, h)
+	}
+	return append(problems, e.Missing...)
+}
+
+/*
+Go 1.18, which go.mod still names, has no errors.Is or errors.As for several wrapped errors at once.
+With these two methods errors.Is(err, ErrHeaderInjection) finds an injection attempt in any header on every
+version, and so does errors.As for a *HeaderError.
+*/
+
+func (e *ValidationError) Is(target error) bool {
+	for _, p := range e.Unwrap() {
+		if errors.Is(p, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *ValidationError) As(target interface{}) bool {
+	for _, p := range e.Unwrap() {
+		if errors.As(p, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *EmailBuilder) fail(header string, err error) {
+	b.errs = append(b.errs, &HeaderError{header, err})
+}
+
+// A line break in a header value would let the caller add headers of their own, such as an extra Bcc.
+func hasLineBreak(s string) bool {
+	return strings.ContainsAny(s, "\r\n")
+}
+
+// parseAddress accepts anything RFC 5322 allows for a single mailbox, such as "Jane Doe <jane@example.com>".
+func (b *EmailBuilder) parseAddress(field, address string) *mail.Address {
+	if hasLineBreak(address) {
+		b.fail(field, fmt.Errorf("%w in %q", ErrHeaderInjection, address))
+		return nil
+	}
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		b.fail(field, fmt.Errorf("%w %q: %v", ErrInvalidAddress, address, err))
+		return nil
+	}
+	return parsed
+}
+
+func (b *EmailBuilder) parseAddresses(field string, addresses []string) []*mail.Address {
+	var parsed []*mail.Address
+	for _, address := range addresses {
+		if a := b.parseAddress(field, address); a != nil {
+			parsed = append(parsed, a)
+		}
+	}
+	return parsed
+}
+
+func (b *EmailBuilder) validateSubject(subject string) {
+	if hasLineBreak(subject) {
+		b.fail("subject", ErrHeaderInjection)
+	}
+	if n := utf8.RuneCountInString(subject); n > MaxSubjectLength {
+		b.fail("subject", fmt.Errorf("%w: %d characters, at most %d allowed", ErrSubjectTooLong, n, MaxSubjectLength))
+	}
+}
+
+// validate returns the problems recorded so far, plus the ones only visible once the whole email is known.
+func (b *EmailBuilder) validate() error {
+	var missing []error
+	if b.email.from == nil && !b.fromSet {
+		missing = append(missing, ErrMissingFrom)
+	}
+	if len(b.email.recipients()) == 0 {
+		missing = append(missing, ErrNoRecipients)
+	}
+	if len(b.errs) > 0 || len(missing) > 0 {
+		return &ValidationError{append([]*HeaderError{}, b.errs...), missing}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package builderparameter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSendEmailRejectsEachProblem(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func(b *EmailBuilder)
+		headers []string
+		missing []error
+		is      error
+	}{
+		{"invalid sender", func(b *EmailBuilder) { b.From("not an address").To("ann@example.com") },
+			[]string{"from"}, nil, ErrInvalidAddress},
+		{"one invalid recipient", func(b *EmailBuilder) { b.From("me@example.com").To("ann@example.com", "bob@") },
+			[]string{"to"}, nil, ErrInvalidAddress},
+		{"injected Bcc", func(b *EmailBuilder) { b.From("me@example.com").Cc("ann@example.com\r\nBcc: eve@example.com") },
+			[]string{"cc"}, []error{ErrNoRecipients}, ErrHeaderInjection},
+		{"empty Bcc", func(b *EmailBuilder) { b.From("me@example.com").To("ann@example.com").Bcc("") },
+			[]string{"bcc"}, nil, ErrInvalidAddress},
+		{"line break in the subject", func(b *EmailBuilder) { b.From("me@example.com").To("ann@example.com").Subject("Hi\nX-Spam: no") },
+			[]string{"subject"}, nil, ErrHeaderInjection},
+		{"long subject", func(b *EmailBuilder) {
+			b.From("me@example.com").To("ann@example.com").Subject(strings.Repeat("s", MaxSubjectLength+1))
+		}, []string{"subject"}, nil, ErrSubjectTooLong},
+		{"no sender", func(b *EmailBuilder) { b.To("ann@example.com") },
+			nil, []error{ErrMissingFrom}, ErrMissingFrom},
+		{"no recipients", func(b *EmailBuilder) { b.From("me@example.com").Subject("Hi") },
+			nil, []error{ErrNoRecipients}, ErrNoRecipients},
+		{"nothing at all", func(b *EmailBuilder) {},
+			nil, []error{ErrMissingFrom, ErrNoRecipients}, ErrNoRecipients},
+	}
+	for _, tt := range tests {
+		transport := &MemoryTransport{}
+		err := SendEmailWith(transport, tt.build)
+
+		var v *ValidationError
+		if !errors.As(err, &v) {
+			t.Errorf("%s: SendEmailWith() = %v, want a *ValidationError", tt.name, err)
+			continue
+		}
+		var headers []string
+		for _, h := range v.Headers {
+			headers = append(headers, h.Header)
+		}
+		if !reflect.DeepEqual(headers, tt.headers) || !reflect.DeepEqual(v.Missing, tt.missing) {
+			t.Errorf("%s: headers %q and missing %v, want %q and %v", tt.name, headers, v.Missing, tt.headers, tt.missing)
+		}
+		// Calling Is directly checks the method itself, whatever the errors package of the toolchain does
+		if !errors.Is(err, tt.is) || !v.Is(tt.is) {
+			t.Errorf("%s: %v does not match %v", tt.name, err, tt.is)
+		}
+		if len(transport.Messages()) != 0 {
+			t.Errorf("%s: an invalid email was sent", tt.name)
+		}
+	}
+}
+
+func TestValidationErrorKeepsEveryProblem(t *testing.T) {
+	err := SendEmailWith(&MemoryTransport{}, func(b *EmailBuilder) {
+		b.From("not an address").Subject("a\r\nBcc: x@y.z").Subject(strings.Repeat("é", MaxSubjectLength+1))
+	})
+	var v *ValidationError
+	if !errors.As(err, &v) {
+		t.Fatalf("SendEmailWith() = %v, want a *ValidationError", err)
+	}
+	for _, want := range []error{ErrInvalidAddress, ErrHeaderInjection, ErrSubjectTooLong, ErrNoRecipients} {
+		if !v.Is(want) {
+			t.Errorf("%v does not match %v", err, want)
+		}
+	}
+	// An invalid From was given, so it is not missing as well
+	if v.Is(ErrMissingFrom) {
+		t.Error("an invalid From was reported as missing")
+	}
+	var header *HeaderError
+	if !v.As(&header) || header.Header != "from" {
+		t.Errorf("As found %v, want the from HeaderError first", header)
+	}
+	if !strings.HasPrefix(err.Error(), "invalid email: from: invalid email address") ||
+		!strings.HasSuffix(err.Error(), "; email has no recipients") {
+		t.Errorf("Error() = %q", err)
+	}
+}
+
+func TestSendEmailAcceptsAValidEmail(t *testing.T) {
+	transport := &MemoryTransport{}
+	err := SendEmailWith(transport, func(b *EmailBuilder) {
+		// Only blind copies, and a subject at the limit in characters although it is twice as long in bytes
+		b.From("Jane Doe <jane@example.com>").Bcc("ann@example.com").Subject(strings.Repeat("é", MaxSubjectLength))
+	})
+	if err != nil || len(transport.Messages()) != 1 {
+		t.Errorf("SendEmailWith() = %v, %d messages sent", err, len(transport.Messages()))
+	}
+}
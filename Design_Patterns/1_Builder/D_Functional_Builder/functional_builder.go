@@ -1,6 +1,9 @@
 package functionalbuilder
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 /*
 One way of extending a builder is by using a functional programming approach.
@@ -10,28 +13,26 @@ type Person struct {
 	name, position string
 }
 
-type personMod func(*Person)
+// The list of actions now lives in the generic Builder, see generic_builder.go
 type PersonBuilder struct {
-	actions []personMod
+	actions Builder[Person]
 }
 
 func (b *PersonBuilder) Called(name string) *PersonBuilder {
-	b.actions = append(b.actions, func(p *Person) {
+	b.actions.With(func(p *Person) {
 		p.name = name
 	})
 	return b
 }
 
+// None of the person modifications can fail, so there is no error to return.
 func (b *PersonBuilder) Build() *Person {
-	p := Person{}
-	for _, a := range b.actions {
-		a(&p)
-	}
-	return &p
+	p, _ := b.actions.Build()
+	return p
 }
 
 func (b *PersonBuilder) WorkAsA(position string) *PersonBuilder {
-	b.actions = append(b.actions, func(p *Person) {
+	b.actions.With(func(p *Person) {
 		p.position = position
 	})
 	return b
@@ -41,4 +42,32 @@ func main() {
 	b := PersonBuilder{}
 	p := b.Called("Dimitri").WorkAsA("Developer").Build()
 	fmt.Println(*p)
+
+	// The generic builder works for any struct, for example a server configuration
+	type config struct {
+		host    string
+		port    int
+		tls     bool
+		timeout time.Duration
+	}
+	defaults := NewBuilder[config]().With(func(c *config) {
+		c.host = "localhost"
+		c.port = 8080
+		c.timeout = 30 * time.Second
+	})
+	secure := NewBuilder[config]().With(func(c *config) { c.tls = true })
+
+	production := defaults.Clone().
+		Include(secure).
+		With(func(c *config) { c.host = "example.com" }).
+		When(func(c *config) bool { return c.tls }, NewBuilder[config]().With(func(c *config) { c.port = 443 })).
+		Try(func(c *config) error {
+			if c.timeout <= 0 {
+				return fmt.Errorf("timeout must be positive, got %v", c.timeout)
+			}
+			return nil
+		})
+
+	c, err := production.Build()
+	fmt.Println(c, err)
 }
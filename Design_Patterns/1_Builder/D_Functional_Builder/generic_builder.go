@@ -0,0 +1,95 @@
+package functionalbuilder
+
+/*
+Nothing in the functional builder really depends on Person: it is a list of modifications applied, in order,
+to a fresh value. Builder[T] is that same idea for any type, so it can be reused for config structs and the like.
+
+Because a builder is just a list of actions, builders can be cloned and spliced into each other. That makes presets
+cheap: build up the common part once, then Clone it or Include it wherever it is needed.
+*/
+
+// Action is a modification that can fail. Plain func(*T) modifications are added with With, and never fail.
+type Action[T any] func(*T) error
+
+type Builder[T any] struct {
+	actions []Action[T]
+}
+
+func NewBuilder[T any]() *Builder[T] {
+	return &Builder[T]{}
+}
+
+func (b *Builder[T]) With(mods ...func(*T)) *Builder[T] {
+	for _, mod := range mods {
+		mod := mod
+		b.actions = append(b.actions, func(t *T) error {
+			mod(t)
+			return nil
+		})
+	}
+	return b
+}
+
+// Try adds actions that can fail. Build stops at the first one that returns an error.
+func (b *Builder[T]) Try(actions ...func(*T) error) *Builder[T] {
+	for _, action := range actions {
+		b.actions = append(b.actions, action)
+	}
+	return b
+}
+
+// Include splices the actions another builder has at this moment. Adding to it later does not affect this builder.
+func (b *Builder[T]) Include(others ...*Builder[T]) *Builder[T] {
+	for _, other := range others {
+		b.actions = append(b.actions, other.actions...)
+	}
+	return b
+}
+
+// If includes the actions of then only when cond is true, which keeps a chain going where an if statement would break it.
+func (b *Builder[T]) If(cond bool, then *Builder[T]) *Builder[T] {
+	if cond {
+		b.Include(then)
+	}
+	return b
+}
+
+// When decides at build time: the actions of then run only if pred holds for the value built so far.
+func (b *Builder[T]) When(pred func(*T) bool, then *Builder[T]) *Builder[T] {
+	actions := then.Clone().actions
+	b.actions = append(b.actions, func(t *T) error {
+		if !pred(t) {
+			return nil
+		}
+		return apply(t, actions)
+	})
+	return b
+}
+
+// Clone returns a builder with the same actions, which can then be extended without touching the original.
+func (b *Builder[T]) Clone() *Builder[T] {
+	return &Builder[T]{append([]Action[T]{}, b.actions...)}
+}
+
+func (b *Builder[T]) Build() (*T, error) {
+	var t T
+	return b.BuildFrom(t)
+}
+
+// BuildFrom applies the actions to a copy of initial, for example a value holding the defaults.
+func (b *Builder[T]) BuildFrom(initial T) (*T, error) {
+	t := initial
+	if err := apply(&t, b.actions); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func apply[T any](t *T, actions []Action[T]) error {
+	for _, a := range actions {
+		if err := a(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
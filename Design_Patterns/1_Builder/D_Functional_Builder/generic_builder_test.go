@@ -0,0 +1,122 @@
+package functionalbuilder
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type config struct {
+	Name  string
+	Port  int
+	Steps []string
+}
+
+// step records that it ran, so the tests can see which actions were applied and in which order.
+func step(name string) func(*config) {
+	return func(c *config) { c.Steps = append(c.Steps, name) }
+}
+
+func TestBuildAppliesActionsInOrder(t *testing.T) {
+	b := NewBuilder[config]().
+		With(step("a"), step("b")).
+		Try(func(c *config) error { c.Steps = append(c.Steps, "c"); return nil }).
+		With(func(c *config) { c.Name = "first" }, func(c *config) { c.Name = "second" })
+
+	c, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(c.Steps, want) || c.Name != "second" {
+		t.Errorf("got steps %q and name %q, want %q and the last name set", c.Steps, c.Name, want)
+	}
+
+	// Building again starts from scratch, with every action applied once more
+	again, _ := b.Build()
+	if len(again.Steps) != 3 || again == c {
+		t.Errorf("second build gave %q", again.Steps)
+	}
+}
+
+func TestBuildStopsAtTheFirstError(t *testing.T) {
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	ran := []string{}
+	record := func(name string, err error) func(*config) error {
+		return func(*config) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+
+	c, err := NewBuilder[config]().
+		Try(record("ok", nil), record("fails", errFirst), record("also fails", errSecond)).
+		With(func(*config) { ran = append(ran, "never") }).
+		Build()
+	if c != nil || !errors.Is(err, errFirst) {
+		t.Errorf("Build() = %v, %v, want nil and the first error", c, err)
+	}
+	if want := []string{"ok", "fails"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+
+	// An error inside a When stops the outer builder too
+	inner := NewBuilder[config]().Try(record("inner", errSecond))
+	ran = nil
+	_, err = NewBuilder[config]().When(func(*config) bool { return true }, inner).With(step("after")).Build()
+	if !errors.Is(err, errSecond) {
+		t.Errorf("Build() = %v, want the error of the When builder", err)
+	}
+}
+
+func TestIncludeAndCloneTakeASnapshot(t *testing.T) {
+	preset := NewBuilder[config]().With(step("preset"))
+	b := NewBuilder[config]().With(step("start")).Include(preset)
+	clone := b.Clone().With(step("clone only"))
+
+	// Adding to the preset or the clone afterwards does not change b
+	preset.With(step("added later"))
+	b.With(step("end"))
+
+	c, _ := b.Build()
+	if want := []string{"start", "preset", "end"}; !reflect.DeepEqual(c.Steps, want) {
+		t.Errorf("builder: %q, want %q", c.Steps, want)
+	}
+	c, _ = clone.Build()
+	if want := []string{"start", "preset", "clone only"}; !reflect.DeepEqual(c.Steps, want) {
+		t.Errorf("clone: %q, want %q", c.Steps, want)
+	}
+}
+
+func TestIfDecidesNowAndWhenDecidesAtBuildTime(t *testing.T) {
+	tls := NewBuilder[config]().With(func(c *config) { c.Port = 443 })
+	b := NewBuilder[config]().
+		If(false, NewBuilder[config]().With(step("skipped"))).
+		If(true, NewBuilder[config]().With(step("included"))).
+		When(func(c *config) bool { return c.Name == "secure" }, tls)
+
+	c, _ := b.Build()
+	if c.Port != 0 || !reflect.DeepEqual(c.Steps, []string{"included"}) {
+		t.Errorf("plain build: port %d, steps %q", c.Port, c.Steps)
+	}
+	c, _ = b.BuildFrom(config{Name: "secure", Port: 80})
+	if c.Port != 443 {
+		t.Errorf("port %d, want 443 once the predicate holds", c.Port)
+	}
+
+	// When copied the actions, so changing tls afterwards has no effect
+	tls.With(func(c *config) { c.Port = 8443 })
+	if c, _ = b.BuildFrom(config{Name: "secure"}); c.Port != 443 {
+		t.Errorf("port %d, want 443", c.Port)
+	}
+}
+
+func TestBuildFromLeavesTheInitialValueAlone(t *testing.T) {
+	defaults := config{Name: "default", Port: 80}
+	c, err := NewBuilder[config]().With(func(c *config) { c.Name, c.Port = "custom", 8080 }).BuildFrom(defaults)
+	if err != nil || c.Name != "custom" || c.Port != 8080 {
+		t.Fatalf("BuildFrom() = %+v, %v", c, err)
+	}
+	if defaults.Name != "default" || defaults.Port != 80 {
+		t.Errorf("the defaults changed to %+v", defaults)
+	}
+}
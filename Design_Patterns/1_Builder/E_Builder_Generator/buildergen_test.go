@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// Every directory in testdata holds the input of one package. The expected builder is next to it,
+// as <struct>_builder.go.golden, so the go tool does not mistake it for a source file.
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		dir, style string
+	}{
+		{"functional", styleFunctional},
+		{"faceted", styleFaceted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			dir := filepath.Join("testdata", tt.dir)
+			pkg, err := loadPackage(dir, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := generate(pkg, tt.style)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join(dir, strings.ToLower(pkg.Structs[0].Name)+"_builder.go.golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("generated code does not match %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		dir, style, want string
+	}{
+		{"clashing_methods", styleFunctional, "method Named of field Last clashes with field First"},
+		{"conflicting_facets", styleFaceted, `conflicting builder tag options "facet=Lives" and "facet=Works"`},
+		{"repeated_option", styleFunctional, `conflicting builder tag options "required" and "required"`},
+		{"unknown_option", styleFunctional, `unknown builder tag option "optional"`},
+		{"generic_struct", styleFunctional, "generic struct Box is not supported"},
+		{"bad_facet", styleFaceted, `facet "lives" is not an exported identifier`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			pkg, err := loadPackage(filepath.Join("testdata", "errors", tt.dir), nil)
+			if err == nil {
+				_, err = generate(pkg, tt.style)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// Running the tool on its own output must not change anything, which is why generated files are skipped.
+func TestGeneratedFilesAreSkipped(t *testing.T) {
+	dir := t.TempDir()
+	src, err := os.ReadFile(filepath.Join("testdata", "functional", "config.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile(filepath.Join("testdata", "functional", "config_builder.go.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"config.go": src, "config_builder.go": golden} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkg, err := loadPackage(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkg.Structs) != 1 || pkg.Structs[0].Name != "Config" {
+		t.Fatalf("found %d structs, want only Config", len(pkg.Structs))
+	}
+}
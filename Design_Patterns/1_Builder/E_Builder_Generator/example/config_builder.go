@@ -0,0 +1,63 @@
+// Code generated by buildergen; DO NOT EDIT.
+
+package example
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ConfigBuilder records every call as an action, and Build applies them in order to a new Config.
+type ConfigBuilder struct {
+	actions []func(*Config)
+	set     map[string]bool
+}
+
+func NewConfigBuilder() *ConfigBuilder {
+	return &ConfigBuilder{}
+}
+
+func (b *ConfigBuilder) WithHost(value string) *ConfigBuilder {
+	b.actions = append(b.actions, func(v *Config) {
+		v.Host = value
+	})
+	if b.set == nil {
+		b.set = map[string]bool{}
+	}
+	b.set["Host"] = true
+	return b
+}
+
+func (b *ConfigBuilder) WithPort(value int) *ConfigBuilder {
+	b.actions = append(b.actions, func(v *Config) {
+		v.Port = value
+	})
+	return b
+}
+
+func (b *ConfigBuilder) WithTimeout(value time.Duration) *ConfigBuilder {
+	b.actions = append(b.actions, func(v *Config) {
+		v.Timeout = value
+	})
+	return b
+}
+
+func (b *ConfigBuilder) missing() []string {
+	var missing []string
+	if !b.set["Host"] {
+		missing = append(missing, "Host")
+	}
+	return missing
+}
+
+func (b *ConfigBuilder) Build() (*Config, error) {
+	if missing := b.missing(); len(missing) > 0 {
+		return nil, fmt.Errorf("ConfigBuilder: required fields not set: %s", strings.Join(missing, ", "))
+	}
+	v := Config{}
+	for _, a := range b.actions {
+		a(&v)
+	}
+	return &v, nil
+}
@@ -0,0 +1,30 @@
+package example
+
+import "time"
+
+/*
+Running go generate in this folder writes person_builder.go and config_builder.go next to this file.
+Person gets a faceted builder like the one in B_Builder_Facets, and Config a functional one like in D_Functional_Builder.
+*/
+
+//go:generate go run github.com/Germanchrystan/design_patterns_course/Design_Patterns/1_Builder/E_Builder_Generator -type Person -style faceted
+//go:generate go run github.com/Germanchrystan/design_patterns_course/Design_Patterns/1_Builder/E_Builder_Generator -type Config
+
+type Person struct {
+	Name string `builder:"Called,required"`
+	// address
+	StreetAddress string `builder:"At,facet=Lives"`
+	Postcode      string `builder:"WithPostcode,facet=Lives"`
+	City          string `builder:"In,facet=Lives"`
+	// job
+	CompanyName  string `builder:"At,facet=Works"`
+	Position     string `builder:"AsA,facet=Works"`
+	AnnualIncome int    `builder:"Earning,facet=Works"`
+}
+
+type Config struct {
+	Host    string `builder:",required"`
+	Port    int
+	Timeout time.Duration
+	cache   map[string][]byte `builder:"-"`
+}
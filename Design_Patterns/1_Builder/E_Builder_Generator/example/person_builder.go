@@ -0,0 +1,92 @@
+// Code generated by buildergen; DO NOT EDIT.
+
+package example
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PersonBuilder changes a single Person. Its facets share that value, and Build returns a copy of it.
+type PersonBuilder struct {
+	target *Person
+	set    map[string]bool
+}
+
+func NewPersonBuilder() *PersonBuilder {
+	return &PersonBuilder{&Person{}, map[string]bool{}}
+}
+
+func (b *PersonBuilder) Called(value string) *PersonBuilder {
+	b.target.Name = value
+	b.set["Name"] = true
+	return b
+}
+
+type PersonLivesBuilder struct {
+	PersonBuilder
+}
+
+func (b *PersonBuilder) Lives() *PersonLivesBuilder {
+	return &PersonLivesBuilder{*b}
+}
+
+func (b *PersonLivesBuilder) At(value string) *PersonLivesBuilder {
+	b.target.StreetAddress = value
+	b.set["StreetAddress"] = true
+	return b
+}
+
+func (b *PersonLivesBuilder) WithPostcode(value string) *PersonLivesBuilder {
+	b.target.Postcode = value
+	b.set["Postcode"] = true
+	return b
+}
+
+func (b *PersonLivesBuilder) In(value string) *PersonLivesBuilder {
+	b.target.City = value
+	b.set["City"] = true
+	return b
+}
+
+type PersonWorksBuilder struct {
+	PersonBuilder
+}
+
+func (b *PersonBuilder) Works() *PersonWorksBuilder {
+	return &PersonWorksBuilder{*b}
+}
+
+func (b *PersonWorksBuilder) At(value string) *PersonWorksBuilder {
+	b.target.CompanyName = value
+	b.set["CompanyName"] = true
+	return b
+}
+
+func (b *PersonWorksBuilder) AsA(value string) *PersonWorksBuilder {
+	b.target.Position = value
+	b.set["Position"] = true
+	return b
+}
+
+func (b *PersonWorksBuilder) Earning(value int) *PersonWorksBuilder {
+	b.target.AnnualIncome = value
+	b.set["AnnualIncome"] = true
+	return b
+}
+
+func (b *PersonBuilder) missing() []string {
+	var missing []string
+	if !b.set["Name"] {
+		missing = append(missing, "Name")
+	}
+	return missing
+}
+
+func (b *PersonBuilder) Build() (*Person, error) {
+	if missing := b.missing(); len(missing) > 0 {
+		return nil, fmt.Errorf("PersonBuilder: required fields not set: %s", strings.Join(missing, ", "))
+	}
+	v := *b.target
+	return &v, nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"text/template"
+)
+
+const (
+	styleFunctional = "functional"
+	styleFaceted    = "faceted"
+)
+
+type builderView struct {
+	*structInfo
+	Required []*fieldInfo
+	// Faceted style only: the fields without a facet, and the facets in the order they first appear.
+	RootFields []*fieldInfo
+	Facets     []*facetView
+}
+
+type facetView struct {
+	Name   string
+	Fields []*fieldInfo
+}
+
+func generate(pkg *packageInfo, style string) ([]byte, error) {
+	data := struct {
+		Package  string
+		Imports  []importInfo
+		Builders []*builderView
+		Required bool
+	}{Package: pkg.Name, Imports: pkg.Imports}
+
+	for _, s := range pkg.Structs {
+		view := newBuilderView(s)
+		if err := checkMethodNames(view, style); err != nil {
+			return nil, err
+		}
+		data.Builders = append(data.Builders, view)
+		data.Required = data.Required || len(view.Required) > 0
+	}
+
+	tmpl := functionalTemplate
+	if style == styleFaceted {
+		tmpl = facetedTemplate
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+func newBuilderView(s *structInfo) *builderView {
+	view := &builderView{structInfo: s}
+	facets := map[string]*facetView{}
+	for _, f := range s.Fields {
+		if f.Required {
+			view.Required = append(view.Required, f)
+		}
+		if f.Facet == "" {
+			view.RootFields = append(view.RootFields, f)
+			continue
+		}
+		facet, ok := facets[f.Facet]
+		if !ok {
+			facet = &facetView{Name: f.Facet}
+			facets[f.Facet] = facet
+			view.Facets = append(view.Facets, facet)
+		}
+		facet.Fields = append(facet.Fields, f)
+	}
+	return view
+}
+
+// Two methods with the same name on one builder would not compile, and a facet method named like a facet
+// would hide the way to that facet, so both are reported here with the names of the fields involved.
+func checkMethodNames(view *builderView, style string) error {
+	check := func(builder string, taken map[string]string, fields []*fieldInfo) error {
+		for _, f := range fields {
+			if other, ok := taken[f.Method]; ok {
+				return fmt.Errorf("%s: method %s of field %s clashes with %s", builder, f.Method, f.Name, other)
+			}
+			taken[f.Method] = "field " + f.Name
+		}
+		return nil
+	}
+
+	builder := view.Name + "Builder"
+	root := map[string]string{"Build": "the Build method"}
+	if style == styleFunctional {
+		return check(builder, root, view.Fields)
+	}
+	for _, facet := range view.Facets {
+		root[facet.Name] = "facet " + facet.Name
+	}
+	if err := check(builder, root, view.RootFields); err != nil {
+		return err
+	}
+	for _, facet := range view.Facets {
+		taken := map[string]string{"Build": "the Build method"}
+		for _, other := range view.Facets {
+			taken[other.Name] = "facet " + other.Name
+		}
+		if err := check(view.Name+facet.Name+"Builder", taken, facet.Fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const header = `// Code generated by buildergen; DO NOT EDIT.
+
+package {{.Package}}
+{{if or .Required .Imports}}
+import (
+{{- if .Required}}
+	"fmt"
+	"strings"
+{{- end}}
+{{- range .Imports}}
+	{{.Name}} "{{.Path}}"
+{{- end}}
+)
+{{end}}`
+
+// The missing method is shared by both styles. It lists required fields in declaration order.
+const missing = `{{define "missing"}}
+{{- if .Required}}
+func (b *{{.Name}}Builder) missing() []string {
+	var missing []string
+{{- range .Required}}
+	if !b.set["{{.Name}}"] {
+		missing = append(missing, "{{.Name}}")
+	}
+{{- end}}
+	return missing
+}
+{{end}}
+{{- end}}
+{{define "check"}}
+{{- if .Required}}
+	if missing := b.missing(); len(missing) > 0 {
+		return nil, fmt.Errorf("{{.Name}}Builder: required fields not set: %s", strings.Join(missing, ", "))
+	}
+{{- end}}
+{{- end}}`
+
+var functionalTemplate = template.Must(template.New("functional").Parse(header + missing + `
+{{range .Builders}}
+// {{.Name}}Builder records every call as an action, and Build applies them in order to a new {{.Name}}.
+type {{.Name}}Builder struct {
+	actions []func(*{{.Name}})
+{{- if .Required}}
+	set     map[string]bool
+{{- end}}
+}
+
+func New{{.Name}}Builder() *{{.Name}}Builder {
+	return &{{.Name}}Builder{}
+}
+{{$builder := .}}
+{{- range .Fields}}
+func (b *{{$builder.Name}}Builder) {{.Method}}(value {{.Type}}) *{{$builder.Name}}Builder {
+	b.actions = append(b.actions, func(v *{{$builder.Name}}) {
+		v.{{.Name}} = value
+	})
+{{- if .Required}}
+	if b.set == nil {
+		b.set = map[string]bool{}
+	}
+	b.set["{{.Name}}"] = true
+{{- end}}
+	return b
+}
+{{end}}
+{{- template "missing" .}}
+func (b *{{.Name}}Builder) Build() (*{{.Name}}, error) {
+{{- template "check" .}}
+	v := {{.Name}}{}
+	for _, a := range b.actions {
+		a(&v)
+	}
+	return &v, nil
+}
+{{end}}`))
+
+var facetedTemplate = template.Must(template.New("faceted").Parse(header + missing + `
+{{range .Builders}}{{$builder := .}}
+// {{.Name}}Builder changes a single {{.Name}}. Its facets share that value, and Build returns a copy of it.
+type {{.Name}}Builder struct {
+	target *{{.Name}}
+	set    map[string]bool
+}
+
+func New{{.Name}}Builder() *{{.Name}}Builder {
+	return &{{.Name}}Builder{&{{.Name}}{}, map[string]bool{}}
+}
+{{range .RootFields}}
+func (b *{{$builder.Name}}Builder) {{.Method}}(value {{.Type}}) *{{$builder.Name}}Builder {
+	b.target.{{.Name}} = value
+	b.set["{{.Name}}"] = true
+	return b
+}
+{{end}}
+{{- range .Facets}}{{$facet := .}}
+type {{$builder.Name}}{{.Name}}Builder struct {
+	{{$builder.Name}}Builder
+}
+
+func (b *{{$builder.Name}}Builder) {{.Name}}() *{{$builder.Name}}{{.Name}}Builder {
+	return &{{$builder.Name}}{{.Name}}Builder{*b}
+}
+{{range .Fields}}
+func (b *{{$builder.Name}}{{$facet.Name}}Builder) {{.Method}}(value {{.Type}}) *{{$builder.Name}}{{$facet.Name}}Builder {
+	b.target.{{.Name}} = value
+	b.set["{{.Name}}"] = true
+	return b
+}
+{{end}}
+{{- end}}
+{{- template "missing" .}}
+func (b *{{.Name}}Builder) Build() (*{{.Name}}, error) {
+{{- template "check" .}}
+	v := *b.target
+	return &v, nil
+}
+{{end}}`))
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Every builder in the previous folders was written by hand, and they all look the same: one method per field,
+each returning the builder so the calls can be chained. That is exactly the kind of code a machine should write.
+
+This tool reads the struct definitions of a package and writes a fluent builder for each selected struct.
+It is meant to be run by go generate, for example:
+
+	//go:generate go run github.com/Germanchrystan/design_patterns_course/Design_Patterns/1_Builder/E_Builder_Generator -type Person -style faceted
+
+Structs are selected with -type, or by putting a //builder:generate line in their doc comment.
+The generated builders follow the style of D_Functional_Builder (-style functional, the default),
+or the style of B_Builder_Facets (-style faceted).
+
+Struct tags tune each field, much like json tags do:
+
+	Name string `builder:"Called,required"`          // method name, and Build fails if it was never called
+	City string `builder:"In,facet=Lives"`            // faceted style: the method goes on the Lives facet
+	Age  int    `builder:",required"`                 // default method name (WithAge), but required
+	Temp string `builder:"-"`                         // no method at all
+*/
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("buildergen: ")
+
+	typeNames := flag.String("type", "", "comma-separated list of struct names; defaults to structs marked with //builder:generate")
+	style := flag.String("style", styleFunctional, "builder style: functional or faceted")
+	output := flag.String("output", "", "output file name; defaults to <first type>_builder.go in the package directory")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: buildergen [flags] [directory]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	if *style != styleFunctional && *style != styleFaceted {
+		log.Fatalf("unknown style %q, expected %s or %s", *style, styleFunctional, styleFaceted)
+	}
+
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	pkg, err := loadPackage(dir, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(pkg.Structs) == 0 {
+		log.Fatalf("no structs to generate builders for in %s", dir)
+	}
+
+	src, err := generate(pkg, *style)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	name := *output
+	if name == "" {
+		name = filepath.Join(dir, strings.ToLower(pkg.Structs[0].Name)+"_builder.go")
+	}
+	if err := os.WriteFile(name, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
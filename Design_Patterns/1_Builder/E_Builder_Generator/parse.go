@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const directive = "//builder:generate"
+
+type packageInfo struct {
+	Name    string
+	Structs []*structInfo
+	// Imports needed by the field types of the selected structs.
+	Imports []importInfo
+}
+
+type importInfo struct {
+	Name, Path string // Name is only set when it differs from the last element of Path
+}
+
+type structInfo struct {
+	Name   string
+	Fields []*fieldInfo
+}
+
+type fieldInfo struct {
+	Name, Type string
+	Method     string
+	Facet      string
+	Required   bool
+}
+
+func loadPackage(dir string, typeNames []string) (*packageInfo, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	wanted := map[string]bool{}
+	for _, name := range typeNames {
+		wanted[strings.TrimSpace(name)] = true
+	}
+
+	fset := token.NewFileSet()
+	pkg := &packageInfo{}
+	imports := map[importInfo]bool{}
+	found := map[string]bool{}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if isGenerated(f) {
+			continue
+		}
+		if pkg.Name != "" && pkg.Name != f.Name.Name {
+			return nil, fmt.Errorf("%s: package %s, expected %s", file, f.Name.Name, pkg.Name)
+		}
+		pkg.Name = f.Name.Name
+
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				if len(wanted) > 0 && !wanted[ts.Name.Name] || len(wanted) == 0 && !hasDirective(doc) {
+					continue
+				}
+				if ts.TypeParams != nil && len(ts.TypeParams.List) > 0 {
+					return nil, fmt.Errorf("%s: generic struct %s is not supported", fset.Position(ts.Pos()), ts.Name.Name)
+				}
+
+				info, err := readStruct(fset, ts.Name.Name, st)
+				if err != nil {
+					return nil, err
+				}
+				pkg.Structs = append(pkg.Structs, info)
+				found[ts.Name.Name] = true
+				for _, imp := range usedImports(f, st) {
+					imports[imp] = true
+				}
+			}
+		}
+	}
+
+	for name := range wanted {
+		if !found[name] {
+			return nil, fmt.Errorf("struct %s not found in %s", name, dir)
+		}
+	}
+	for imp := range imports {
+		pkg.Imports = append(pkg.Imports, imp)
+	}
+	sort.Slice(pkg.Imports, func(i, j int) bool { return pkg.Imports[i].Path < pkg.Imports[j].Path })
+	return pkg, nil
+}
+
+// Our own output, and any other generated file, is skipped so that running the tool twice gives the same result.
+func isGenerated(f *ast.File) bool {
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, c := range group.List {
+			if strings.HasPrefix(c.Text, "// Code generated ") && strings.HasSuffix(c.Text, " DO NOT EDIT.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+func readStruct(fset *token.FileSet, name string, st *ast.StructType) (*structInfo, error) {
+	info := &structInfo{Name: name}
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			continue // embedded fields have no name to set
+		}
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, fset, field.Type); err != nil {
+			return nil, err
+		}
+		tag := ""
+		if field.Tag != nil {
+			unquoted, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", fset.Position(field.Tag.Pos()), err)
+			}
+			tag, _ = reflect.StructTag(unquoted).Lookup("builder")
+		}
+		if tag == "-" {
+			continue
+		}
+
+		for _, ident := range field.Names {
+			f := &fieldInfo{Name: ident.Name, Type: buf.String(), Method: "With" + exported(ident.Name)}
+			if err := parseTag(f, tag); err != nil {
+				return nil, fmt.Errorf("%s: field %s.%s: %v", fset.Position(ident.Pos()), name, ident.Name, err)
+			}
+			info.Fields = append(info.Fields, f)
+		}
+	}
+	return info, nil
+}
+
+// Tags follow the encoding/json convention: the method name first, then comma separated options.
+func parseTag(f *fieldInfo, tag string) error {
+	if tag == "" {
+		return nil
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] != "" {
+		f.Method = parts[0]
+	}
+	// Repeating an option is at best redundant, and two facets for one field cannot both be right.
+	seen := map[string]string{}
+	for _, option := range parts[1:] {
+		key := strings.SplitN(option, "=", 2)[0]
+		if previous, ok := seen[key]; ok {
+			return fmt.Errorf("conflicting builder tag options %q and %q", previous, option)
+		}
+		seen[key] = option
+		switch {
+		case option == "required":
+			f.Required = true
+		case strings.HasPrefix(option, "facet="):
+			f.Facet = strings.TrimPrefix(option, "facet=")
+			if !token.IsIdentifier(f.Facet) || !token.IsExported(f.Facet) {
+				return fmt.Errorf("facet %q is not an exported identifier", f.Facet)
+			}
+		default:
+			return fmt.Errorf("unknown builder tag option %q", option)
+		}
+	}
+	if !token.IsIdentifier(f.Method) {
+		return fmt.Errorf("method name %q is not an identifier", f.Method)
+	}
+	return nil
+}
+
+// usedImports finds the packages the field types refer to, like time in time.Duration.
+func usedImports(f *ast.File, st *ast.StructType) []importInfo {
+	names := map[string]bool{}
+	ast.Inspect(st, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				names[x.Name] = true
+			}
+		}
+		return true
+	})
+
+	var used []importInfo
+	for _, spec := range f.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if !names[name] {
+			continue
+		}
+		imp := importInfo{Path: p}
+		if name != path.Base(p) {
+			imp.Name = name
+		}
+		used = append(used, imp)
+	}
+	return used
+}
+
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
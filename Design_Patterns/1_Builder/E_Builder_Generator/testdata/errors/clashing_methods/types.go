@@ -0,0 +1,7 @@
+package sample
+
+//builder:generate
+type Person struct {
+	First string `builder:"Named"`
+	Last  string `builder:"Named"`
+}
@@ -0,0 +1,6 @@
+package sample
+
+//builder:generate
+type Person struct {
+	City string `builder:"In,facet=Lives,facet=Works"`
+}
@@ -0,0 +1,6 @@
+package sample
+
+//builder:generate
+type Box[T any] struct {
+	Value T
+}
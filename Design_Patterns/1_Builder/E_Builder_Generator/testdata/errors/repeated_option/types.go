@@ -0,0 +1,6 @@
+package sample
+
+//builder:generate
+type Person struct {
+	Name string `builder:"Called,required,required"`
+}
@@ -0,0 +1,13 @@
+package sample
+
+//builder:generate
+type Person struct {
+	Name string `builder:"Called,required"`
+	// address
+	StreetAddress string `builder:"At,facet=Lives"`
+	City          string `builder:"In,facet=Lives,required"`
+	// job
+	CompanyName  string `builder:"At,facet=Works"`
+	AnnualIncome int    `builder:"Earning,facet=Works"`
+	Nickname     string
+}
@@ -0,0 +1,21 @@
+package sample
+
+import (
+	"net/url"
+	t "time"
+)
+
+//builder:generate
+type Config struct {
+	Host    string `builder:",required"`
+	Port    int
+	Timeout t.Duration `builder:"WaitingFor"`
+	Proxy   *url.URL
+	Tags    []string
+	cache   map[string][]byte `builder:"-"`
+}
+
+// Not marked, so no builder is generated for it.
+type ignored struct {
+	Name string
+}
@@ -1,5 +1,10 @@
 package factoryfunction
 
+import (
+	"errors"
+	"fmt"
+)
+
 type Person struct {
 	Name string
 	Age  int
@@ -11,10 +16,8 @@ type Person struct {
 // A factory function is nothing more than a freestanding function
 // which returns an instance of the struct you want to create.
 func NewPerson(name string, age int) *Person {
-	// This function could also implement validation
-	if age < 16 {
-		// ...
-	}
+	// This function could also implement validation.
+	// See NewPersonWithPolicy in policy.go for a version that does, and reports what went wrong.
 	return &Person{name, age, 2}
 }
 
@@ -22,4 +25,12 @@ func main() {
 	p := NewPerson("John", 33)
 	// Object could be customized later
 	p.EyeCount = 1
+
+	// With a policy, invalid people are never created, and the error says why
+	if _, err := NewPersonWithPolicy("  Tim ", 12, DefaultPolicy); errors.Is(err, ErrInvalidAge) {
+		fmt.Println(err)
+	}
+	if _, err := DecodePerson([]byte(`{"Name": "Cyclops", "Age": 30, "EyeCount": 3}`), DefaultPolicy); err != nil {
+		fmt.Println(err)
+	}
 }
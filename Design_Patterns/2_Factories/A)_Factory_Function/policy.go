@@ -0,0 +1,166 @@
+package factoryfunction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+A factory function is the natural place for validation, but hard-coding the rules into it means every caller
+gets the same ones. Instead, the rules are gathered in a Policy, and the factory takes the policy as a parameter.
+
+Checking once is not enough when the fields are exported, since anybody could set EyeCount to 3 right after.
+So the factory returns a ValidPerson, which can only be read, or changed through Update, which checks the policy again.
+Decoding from JSON also takes the policy as a parameter, so there is no way around it either.
+*/
+
+var (
+	ErrInvalidAge        = errors.New("invalid age")
+	ErrInvalidName       = errors.New("invalid name")
+	ErrInvariantViolated = errors.New("invariant violated")
+)
+
+// AgeError is returned when the age is out of the bounds of the policy. errors.Is(err, ErrInvalidAge) reports true for it.
+type AgeError struct {
+	Age, Min, Max int
+}
+
+func (e *AgeError) Error() string {
+	if e.Max > 0 {
+		return fmt.Sprintf("age %d is not between %d and %d", e.Age, e.Min, e.Max)
+	}
+	return fmt.Sprintf("age %d is below %d", e.Age, e.Min)
+}
+
+func (e *AgeError) Unwrap() error {
+	return ErrInvalidAge
+}
+
+// InvariantError is returned by the invariants of a policy. errors.Is(err, ErrInvariantViolated) reports true for it.
+type InvariantError struct {
+	Field, Reason string
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+func (e *InvariantError) Unwrap() error {
+	return ErrInvariantViolated
+}
+
+type Policy struct {
+	// MinAge and MaxAge are inclusive. A MaxAge of zero means there is no upper bound.
+	MinAge, MaxAge int
+	// NormalizeName is applied before the name is checked. Nil leaves the name as it is.
+	NormalizeName func(name string) string
+	// Invariants are checked last, on the person as it would be returned.
+	Invariants []func(p *Person) error
+}
+
+// DefaultPolicy is what the course example had in mind: nobody under 16, tidy names and at most two eyes.
+var DefaultPolicy = Policy{
+	MinAge:        16,
+	MaxAge:        150,
+	NormalizeName: CollapseSpaces,
+	Invariants:    []func(*Person) error{ValidEyeCount},
+}
+
+// CollapseSpaces trims the name and replaces any run of whitespace inside it with a single space.
+func CollapseSpaces(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func ValidEyeCount(p *Person) error {
+	if p.EyeCount < 0 || p.EyeCount > 2 {
+		return &InvariantError{"EyeCount", fmt.Sprintf("%d is not between 0 and 2", p.EyeCount)}
+	}
+	return nil
+}
+
+// ValidPerson is a Person that keeps to its policy for as long as it exists.
+type ValidPerson struct {
+	person Person
+	policy Policy
+}
+
+func (v *ValidPerson) Name() string {
+	return v.person.Name
+}
+
+func (v *ValidPerson) Age() int {
+	return v.person.Age
+}
+
+func (v *ValidPerson) EyeCount() int {
+	return v.person.EyeCount
+}
+
+// Person returns a copy, which can be changed freely without affecting the ValidPerson.
+func (v *ValidPerson) Person() Person {
+	return v.person
+}
+
+// Update applies change to a copy of the person, and keeps the result only if it still keeps to the policy.
+func (v *ValidPerson) Update(change func(p *Person)) error {
+	p := v.person
+	change(&p)
+	if err := v.policy.Apply(&p); err != nil {
+		return err
+	}
+	v.person = p
+	return nil
+}
+
+// NewPersonWithPolicy is NewPerson with validation. It never returns a person that breaks the policy.
+func NewPersonWithPolicy(name string, age int, policy Policy) (*ValidPerson, error) {
+	return newValidPerson(Person{name, age, 2}, policy)
+}
+
+func newValidPerson(p Person, policy Policy) (*ValidPerson, error) {
+	if err := policy.Apply(&p); err != nil {
+		return nil, err
+	}
+	return &ValidPerson{p, policy}, nil
+}
+
+// Apply normalises the person in place and then checks it against the policy.
+func (policy Policy) Apply(p *Person) error {
+	if policy.NormalizeName != nil {
+		p.Name = policy.NormalizeName(p.Name)
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidName)
+	}
+	if p.Age < policy.MinAge || policy.MaxAge > 0 && p.Age > policy.MaxAge {
+		return &AgeError{p.Age, policy.MinAge, policy.MaxAge}
+	}
+	for _, invariant := range policy.Invariants {
+		if err := invariant(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodePerson reads a person from JSON with the given policy. A missing EyeCount gets the same default as in NewPerson.
+func DecodePerson(data []byte, policy Policy) (*ValidPerson, error) {
+	p := Person{EyeCount: 2}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return newValidPerson(p, policy)
+}
+
+func (v *ValidPerson) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.person)
+}
+
+var ErrNoPolicy = errors.New("a ValidPerson can only be decoded with DecodePerson, which takes the policy")
+
+// UnmarshalJSON has no policy to check the person against, so json.Unmarshal fails instead of skipping the validation.
+func (v *ValidPerson) UnmarshalJSON(data []byte) error {
+	return ErrNoPolicy
+}
@@ -0,0 +1,146 @@
+package factoryfunction
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestNewPersonWithPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		age  int
+		want string
+		is   error
+	}{
+		{"  Ann   Lee ", 30, "Ann Lee", nil},
+		{"Tim", 15, "", ErrInvalidAge},
+		{"Old", 151, "", ErrInvalidAge},
+		{" \t ", 30, "", ErrInvalidName},
+	}
+	for _, tt := range tests {
+		p, err := NewPersonWithPolicy(tt.name, tt.age, DefaultPolicy)
+		if tt.is != nil {
+			if !errors.Is(err, tt.is) || p != nil {
+				t.Errorf("NewPersonWithPolicy(%q, %d) = %v, %v, want %v", tt.name, tt.age, p, err, tt.is)
+			}
+			continue
+		}
+		if err != nil || p.Name() != tt.want || p.Age() != tt.age || p.EyeCount() != 2 {
+			t.Errorf("NewPersonWithPolicy(%q, %d) = %+v, %v", tt.name, tt.age, p, err)
+		}
+	}
+
+	var ageErr *AgeError
+	if _, err := NewPersonWithPolicy("Tim", 12, DefaultPolicy); !errors.As(err, &ageErr) || ageErr.Min != 16 || ageErr.Max != 150 {
+		t.Errorf("got %v, want an AgeError with the bounds of the policy", err)
+	}
+}
+
+func TestUpdateKeepsTheInvariants(t *testing.T) {
+	p, err := NewPersonWithPolicy("Ann", 30, DefaultPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Update(func(p *Person) { p.EyeCount = 3 })
+	var invariant *InvariantError
+	if !errors.Is(err, ErrInvariantViolated) || !errors.As(err, &invariant) || invariant.Field != "EyeCount" {
+		t.Errorf("Update(EyeCount = 3) = %v, want an InvariantError for EyeCount", err)
+	}
+	if err := p.Update(func(p *Person) { p.Age, p.EyeCount = 10, 1 }); !errors.Is(err, ErrInvalidAge) {
+		t.Errorf("Update(Age = 10) = %v, want ErrInvalidAge", err)
+	}
+	if p.EyeCount() != 2 || p.Age() != 30 {
+		t.Errorf("a failed update changed the person to %+v", p.Person())
+	}
+
+	if err := p.Update(func(p *Person) { p.Name, p.EyeCount = " Ann  Smith", 1 }); err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "Ann Smith" || p.EyeCount() != 1 {
+		t.Errorf("after a good update: %+v", p.Person())
+	}
+
+	// The copy handed out is not the person itself
+	copied := p.Person()
+	copied.EyeCount = 7
+	if p.EyeCount() != 1 {
+		t.Error("changing the copy changed the person")
+	}
+}
+
+func TestCustomInvariants(t *testing.T) {
+	errNoBob := errors.New("no Bobs")
+	policy := Policy{
+		MinAge: 0,
+		Invariants: []func(*Person) error{
+			ValidEyeCount,
+			func(p *Person) error {
+				if p.Name == "Bob" {
+					return errNoBob
+				}
+				return nil
+			},
+		},
+	}
+	if _, err := NewPersonWithPolicy("Bob", 1, policy); !errors.Is(err, errNoBob) {
+		t.Errorf("got %v, want the custom invariant to fail", err)
+	}
+	// Without NormalizeName, the name is kept as it is
+	if p, err := NewPersonWithPolicy(" Baby  ", 0, policy); err != nil || p.Name() != " Baby  " {
+		t.Errorf("got %v, %v", p, err)
+	}
+}
+
+func TestDecodePersonUsesTheGivenPolicy(t *testing.T) {
+	child := Policy{MinAge: 0, MaxAge: 17, Invariants: []func(*Person) error{ValidEyeCount}}
+	tests := []struct {
+		json   string
+		policy Policy
+		eyes   int
+		is     error
+	}{
+		{`{"Name": "Ann", "Age": 30}`, DefaultPolicy, 2, nil}, // A missing EyeCount gets the default
+		{`{"Name": "Ann", "Age": 30, "EyeCount": 1}`, DefaultPolicy, 1, nil},
+		{`{"Name": "Cyclops", "Age": 30, "EyeCount": 3}`, DefaultPolicy, 0, ErrInvariantViolated},
+		{`{"Name": "Tim", "Age": 12}`, DefaultPolicy, 0, ErrInvalidAge},
+		{`{"Name": "Tim", "Age": 12}`, child, 2, nil},
+		{`{"Name": "Ann", "Age": 30}`, child, 0, ErrInvalidAge},
+		{`{"Age": 30}`, DefaultPolicy, 0, ErrInvalidName},
+	}
+	for _, tt := range tests {
+		p, err := DecodePerson([]byte(tt.json), tt.policy)
+		if tt.is != nil {
+			if !errors.Is(err, tt.is) {
+				t.Errorf("DecodePerson(%s) = %v, want %v", tt.json, err, tt.is)
+			}
+			continue
+		}
+		if err != nil || p.EyeCount() != tt.eyes {
+			t.Errorf("DecodePerson(%s) = %+v, %v", tt.json, p, err)
+		}
+	}
+
+	var syntax *json.SyntaxError
+	if _, err := DecodePerson([]byte(`{"Name": `), DefaultPolicy); !errors.As(err, &syntax) {
+		t.Errorf("got %v, want a json.SyntaxError", err)
+	}
+}
+
+func TestValidPersonJSON(t *testing.T) {
+	p, _ := NewPersonWithPolicy("Ann", 30, DefaultPolicy)
+	data, err := json.Marshal(p)
+	if err != nil || string(data) != `{"Name":"Ann","Age":30,"EyeCount":2}` {
+		t.Fatalf("Marshal() = %s, %v", data, err)
+	}
+	if decoded, err := DecodePerson(data, DefaultPolicy); err != nil || decoded.Person() != p.Person() {
+		t.Errorf("round trip gave %+v, %v", decoded, err)
+	}
+
+	// json.Unmarshal would have no policy to check against, so it is refused
+	var v ValidPerson
+	if err := json.Unmarshal(data, &v); !errors.Is(err, ErrNoPolicy) {
+		t.Errorf("json.Unmarshal() = %v, want ErrNoPolicy", err)
+	}
+}
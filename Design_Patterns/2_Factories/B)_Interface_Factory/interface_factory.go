@@ -44,6 +44,7 @@ func (p *tiredPerson) SayHello() {
 /*
 This is a different way of implementing NewPerson, in which depending on the age argument,
 one of two structs is returned. Both conform to the Person interface.
+The registry in registry.go does the same without hard-coding the choice.
 */
 func AnotherImplementationOfNewPerson(name string, age int) Person {
 	if age > 100 {
@@ -56,4 +57,12 @@ func main() {
 	p := NewPerson("James", 35)
 	p.SayHello()
 
+	// The registry picks the implementation, see registry.go
+	for _, reg := range Registrations() {
+		fmt.Println(reg.Name, reg.Priority)
+	}
+	if old, err := NewRegisteredPerson("Matusalen", 969); err == nil {
+		old.SayHello()
+	}
+
 }
@@ -0,0 +1,137 @@
+package interfacefactory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+/*
+AnotherImplementationOfNewPerson has its choice of struct hard-coded. With a registry, every implementation of Person
+registers itself together with a predicate saying when it should be used, and the factory just asks the registry.
+A new persona is then a new package with an init function, and the factory itself never changes:
+
+	func init() {
+		interfacefactory.Register(interfacefactory.Registration{
+			Name:     "grumpy",
+			Priority: 50,
+			Matches:  func(name string, age int) bool { return age > 70 },
+			New:      newGrumpyPerson,
+		})
+	}
+
+Registrations are tried from the highest priority to the lowest, and by name when priorities are equal,
+so the result does not depend on the order in which packages happen to be initialised.
+*/
+
+type Constructor func(name string, age int) Person
+
+type Registration struct {
+	Name     string
+	Priority int
+	// Matches selects this implementation. Nil matches everybody, which is what a fallback should use.
+	Matches func(name string, age int) bool
+	New     Constructor
+}
+
+type Registry struct {
+	mu            sync.RWMutex
+	registrations []Registration // kept sorted, see Register
+}
+
+// DefaultRegistry is what the package level functions use. It comes with the two implementations of this file.
+var DefaultRegistry = &Registry{}
+
+func init() {
+	Register(Registration{
+		Name:     "tired",
+		Priority: 100,
+		Matches:  func(name string, age int) bool { return age > 100 },
+		New:      func(name string, age int) Person { return &tiredPerson{name, age} },
+	})
+	Register(Registration{
+		Name: "person",
+		New:  NewPerson,
+	})
+}
+
+// Register adds an implementation. Registering a name twice, or without a constructor, is a programming error and panics.
+func (r *Registry) Register(reg Registration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if reg.New == nil {
+		panic("interfacefactory: Register " + reg.Name + " without a constructor")
+	}
+	for _, existing := range r.registrations {
+		if existing.Name == reg.Name {
+			panic("interfacefactory: Register called twice for " + reg.Name)
+		}
+	}
+	r.registrations = append(r.registrations, reg)
+	sort.SliceStable(r.registrations, func(i, j int) bool {
+		a, b := r.registrations[i], r.registrations[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.Name < b.Name
+	})
+}
+
+// Registrations lists what is registered, in the order the registrations are tried.
+func (r *Registry) Registrations() []Registration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Registration{}, r.registrations...)
+}
+
+/*
+The predicates and constructors are user code, and may well use the registry themselves, for example to register
+another implementation. So they never run while the lock is held: the registrations are copied, and then called.
+*/
+
+// New creates a Person with the first registration that matches, or returns an error if none does.
+func (r *Registry) New(name string, age int) (Person, error) {
+	for _, reg := range r.Registrations() {
+		if reg.Matches == nil || reg.Matches(name, age) {
+			return reg.New(name, age), nil
+		}
+	}
+	return nil, fmt.Errorf("no Person implementation registered for %s, aged %d", name, age)
+}
+
+// NewNamed skips the predicates and uses the implementation registered under impl.
+func (r *Registry) NewNamed(impl, name string, age int) (Person, error) {
+	reg, ok := r.lookup(impl)
+	if !ok {
+		return nil, fmt.Errorf("no Person implementation registered as %q", impl)
+	}
+	return reg.New(name, age), nil
+}
+
+func (r *Registry) lookup(impl string) (Registration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, reg := range r.registrations {
+		if reg.Name == impl {
+			return reg, true
+		}
+	}
+	return Registration{}, false
+}
+
+func Register(reg Registration) {
+	DefaultRegistry.Register(reg)
+}
+
+func Registrations() []Registration {
+	return DefaultRegistry.Registrations()
+}
+
+// NewRegisteredPerson is AnotherImplementationOfNewPerson, with the choice left to the registry.
+func NewRegisteredPerson(name string, age int) (Person, error) {
+	return DefaultRegistry.New(name, age)
+}
+
+func NewNamedPerson(impl, name string, age int) (Person, error) {
+	return DefaultRegistry.NewNamed(impl, name, age)
+}
@@ -0,0 +1,100 @@
+package interfacefactory
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// A constructor or predicate that uses the registry must not deadlock, so each call gets a deadline.
+func within(t *testing.T, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("deadlock: the registry lock is held while user code runs")
+	}
+}
+
+func TestUserCodeCanUseTheRegistry(t *testing.T) {
+	r := &Registry{}
+	r.Register(Registration{
+		Name: "lazy",
+		Matches: func(name string, age int) bool {
+			return len(r.Registrations()) > 0
+		},
+		New: func(name string, age int) Person {
+			r.Register(Registration{Name: "late-" + name, New: NewPerson})
+			return NewPerson(name, age)
+		},
+	})
+
+	within(t, func() {
+		if _, err := r.New("ann", 30); err != nil {
+			t.Error(err)
+		}
+	})
+	within(t, func() {
+		if _, err := r.NewNamed("lazy", "bob", 40); err != nil {
+			t.Error(err)
+		}
+	})
+	if got := len(r.Registrations()); got != 3 {
+		t.Fatalf("%d registrations, want the original and two added by the constructor", got)
+	}
+}
+
+func TestRegistrationOrder(t *testing.T) {
+	p, err := NewRegisteredPerson("old", 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(*tiredPerson); !ok {
+		t.Errorf("got %T for a 120 year old, want *tiredPerson", p)
+	}
+	if _, err := NewNamedPerson("missing", "x", 1); err == nil {
+		t.Error("NewNamedPerson found an implementation that was never registered")
+	}
+}
+
+// Registrations with the same priority are tried by name, whatever order they were registered in.
+func TestEqualPrioritiesAreOrderedByName(t *testing.T) {
+	orders := [][]string{
+		{"zeta", "mid", "alpha"},
+		{"alpha", "mid", "zeta"},
+		{"mid", "zeta", "alpha"},
+	}
+	for _, order := range orders {
+		r := &Registry{}
+		r.Register(Registration{Name: "fallback", Priority: -1, New: NewPerson})
+		for _, name := range order {
+			name := name
+			r.Register(Registration{
+				Name:     name,
+				Priority: 10,
+				New:      func(string, int) Person { return &tiredPerson{name, 0} },
+			})
+		}
+		r.Register(Registration{Name: "high", Priority: 20, Matches: func(string, int) bool { return false }, New: NewPerson})
+
+		var names []string
+		for _, reg := range r.Registrations() {
+			names = append(names, reg.Name)
+		}
+		if want := []string{"high", "alpha", "mid", "zeta", "fallback"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("registered as %q: tried in the order %q, want %q", order, names, want)
+		}
+		p, err := r.New("ann", 30)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tired, ok := p.(*tiredPerson); !ok || tired.name != "alpha" {
+			t.Errorf("registered as %q: New used %+v, want the alpha registration", order, p)
+		}
+	}
+}
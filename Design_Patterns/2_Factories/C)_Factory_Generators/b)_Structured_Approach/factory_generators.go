@@ -35,6 +35,7 @@ On the other hand, in terms of usability in third party code, providing a functi
 some other piece of API is easier than passing a specialized object, for which the developer has to know
 that it has a "Create" method, for instance. For this last situation, you might try to introduce some sort of
 interface, which tells explicitly that there is a "Create" method to be used.
+The generic approach in c)_Generic_Approach does exactly that, with a factory that is a function and an object at once.
 
 Both options are valid eitherway.
 */
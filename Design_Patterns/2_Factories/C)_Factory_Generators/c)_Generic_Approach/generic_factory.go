@@ -0,0 +1,93 @@
+package factorygenerators
+
+import "fmt"
+
+/*
+	Generic Approach
+The functional and the structured approaches both only work for Employee, and each has a drawback:
+a closure can not be inspected or changed, and a struct has to be passed around as a specialized object.
+
+Go lets a function type have methods. So Factory[T] is a function, which can be called directly,
+and it also has a Create method, which satisfies the Creator interface. A third party API can ask for a Creator[T]
+and be given a Factory[T], or any other type with a Create method.
+*/
+
+// Creator is what third party code should accept when it needs to create values of T.
+type Creator[T any] interface {
+	Create(overrides ...func(*T)) *T
+}
+
+// Factory copies a prototype and then applies the overrides given to that particular call.
+type Factory[T any] func(overrides ...func(*T)) *T
+
+func (f Factory[T]) Create(overrides ...func(*T)) *T {
+	return f(overrides...)
+}
+
+/*
+NewFactory takes the prototype by value, so changing the original afterwards does not affect the factory.
+The copy is shallow: pointers, slices and maps in the prototype are shared by everything the factory creates.
+Defaults are applied to every value, before the overrides of the call.
+*/
+func NewFactory[T any](prototype T, defaults ...func(*T)) Factory[T] {
+	return func(overrides ...func(*T)) *T {
+		v := prototype
+		for _, d := range defaults {
+			d(&v)
+		}
+		for _, o := range overrides {
+			o(&v)
+		}
+		return &v
+	}
+}
+
+// With returns a new factory based on this one, with extra defaults applied after its own.
+func (f Factory[T]) With(defaults ...func(*T)) Factory[T] {
+	return func(overrides ...func(*T)) *T {
+		return f(append(append([]func(*T){}, defaults...), overrides...)...)
+	}
+}
+
+//---------------------------------------------------------------------------------//
+
+type Employee struct {
+	Name, Position string
+	AnnualIncome   int
+}
+
+func WithName(name string) func(*Employee) {
+	return func(e *Employee) { e.Name = name }
+}
+
+func WithIncome(annualIncome int) func(*Employee) {
+	return func(e *Employee) { e.AnnualIncome = annualIncome }
+}
+
+func NewEmployeeFactory(position string, annualIncome int) Factory[Employee] {
+	return NewFactory(Employee{Position: position, AnnualIncome: annualIncome})
+}
+
+// Hire stands for third party code: it does not care which kind of factory it gets.
+func Hire(c Creator[Employee], names ...string) []*Employee {
+	var hired []*Employee
+	for _, name := range names {
+		hired = append(hired, c.Create(WithName(name)))
+	}
+	return hired
+}
+
+func main() {
+	developerFactory := NewEmployeeFactory("Developer", 60000)
+	seniorFactory := developerFactory.With(WithIncome(90000))
+
+	// Called as a function, like in the functional approach
+	developer := developerFactory(WithName("Adam"))
+	// Or as an object, like in the structured approach
+	senior := seniorFactory.Create(WithName("Jane"))
+
+	fmt.Println(developer, senior)
+	for _, e := range Hire(developerFactory, "Kim", "Lee") {
+		fmt.Println(e)
+	}
+}
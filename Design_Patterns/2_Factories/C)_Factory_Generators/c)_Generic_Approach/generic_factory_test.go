@@ -0,0 +1,108 @@
+package factorygenerators
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFactoryAppliesDefaultsThenOverrides(t *testing.T) {
+	var order []string
+	step := func(name string) func(*Employee) {
+		return func(e *Employee) {
+			order = append(order, name)
+			e.Position = name
+		}
+	}
+	f := NewFactory(Employee{Position: "prototype", AnnualIncome: 1}, step("default 1"), step("default 2"))
+
+	e := f(step("override 1"), step("override 2"))
+	if want := []string{"default 1", "default 2", "override 1", "override 2"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("applied %q, want %q", order, want)
+	}
+	if e.Position != "override 2" || e.AnnualIncome != 1 {
+		t.Errorf("got %+v, want the last override on top of the prototype", e)
+	}
+
+	// The overrides of one call do not stick to the factory
+	order = nil
+	if e := f.Create(); e.Position != "default 2" || len(order) != 2 {
+		t.Errorf("second call gave %+v after %q", e, order)
+	}
+}
+
+func TestFactoryCopiesThePrototype(t *testing.T) {
+	prototype := Employee{Position: "Developer", AnnualIncome: 60000}
+	f := NewFactory(prototype)
+	prototype.Position = "changed"
+
+	a, b := f(WithName("Adam")), f(WithName("Jane"))
+	if a == b || a.Name != "Adam" || b.Name != "Jane" {
+		t.Errorf("got %+v and %+v, want two separate employees", a, b)
+	}
+	a.AnnualIncome = 1
+	if a.Position != "Developer" || b.AnnualIncome != 60000 || f().AnnualIncome != 60000 {
+		t.Error("the factory shares its prototype with the values it created, or with the original")
+	}
+
+	// The copy is shallow, as documented: a slice in the prototype is shared
+	type team struct{ Members []string }
+	teams := NewFactory(team{Members: []string{"Ann"}})
+	first, second := teams(), teams()
+	first.Members[0] = "Bob"
+	if second.Members[0] != "Bob" {
+		t.Error("the prototype was deep copied, but NewFactory documents a shallow copy")
+	}
+}
+
+func TestWithLayersDefaults(t *testing.T) {
+	developers := NewEmployeeFactory("Developer", 60000)
+	seniors := developers.With(WithIncome(90000))
+	leads := seniors.With(func(e *Employee) { e.Position = "Lead" })
+
+	tests := []struct {
+		name    string
+		factory Factory[Employee]
+		want    Employee
+	}{
+		{"base", developers, Employee{"Kim", "Developer", 60000}},
+		{"one layer", seniors, Employee{"Kim", "Developer", 90000}},
+		{"two layers", leads, Employee{"Kim", "Lead", 90000}},
+	}
+	for _, tt := range tests {
+		if got := tt.factory(WithName("Kim")); *got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+	// Overrides of the call still come after every layer of defaults
+	if got := leads(WithIncome(1)); got.AnnualIncome != 1 {
+		t.Errorf("override lost under the defaults: %+v", got)
+	}
+}
+
+// otherCreator is a Creator that is not a Factory, which Hire has to accept as well.
+type otherCreator struct{ created int }
+
+func (c *otherCreator) Create(overrides ...func(*Employee)) *Employee {
+	c.created++
+	e := &Employee{Position: "Contractor"}
+	for _, o := range overrides {
+		o(e)
+	}
+	return e
+}
+
+func TestHireAcceptsAnyCreator(t *testing.T) {
+	hired := Hire(NewEmployeeFactory("Developer", 60000), "Kim", "Lee")
+	if len(hired) != 2 || hired[0].Name != "Kim" || hired[1].Name != "Lee" || hired[1].Position != "Developer" {
+		t.Errorf("Hire with a Factory gave %+v", hired)
+	}
+
+	other := &otherCreator{}
+	hired = Hire(other, "Ann")
+	if len(hired) != 1 || hired[0].Name != "Ann" || hired[0].Position != "Contractor" || other.created != 1 {
+		t.Errorf("Hire with another Creator gave %+v", hired)
+	}
+	if hired := Hire(other); len(hired) != 0 {
+		t.Errorf("Hire without names gave %+v", hired)
+	}
+}
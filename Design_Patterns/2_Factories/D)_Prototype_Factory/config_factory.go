@@ -0,0 +1,171 @@
+package prototypefactory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+The switch in NewEmployee means a new role needs a new release. Here the prototypes live in a file instead:
+every role in the file is a pre-filled Employee, and the factory hands out copies of it.
+
+	developer:
+	  position: developer
+	  annual_income: 60000
+
+The format is picked by the extension of the file (.json, .yaml, .yml or .toml), and the keys are the json names
+of the Employee fields, so a field added to Employee can be set from the file right away. Unknown keys are rejected,
+which catches typos before they turn into employees with a zero income.
+
+The file can be reloaded while the process runs. A reload that fails keeps the roles that were loaded before.
+*/
+
+var ErrUnknownRole = errors.New("unknown role")
+
+type EmployeeFactory struct {
+	path string
+
+	mu       sync.RWMutex
+	roles    map[string]Employee
+	modTime  time.Time
+	fileSize int64
+}
+
+// NewEmployeeFactoryFromFile loads the roles once. Call Reload or Watch to pick up later changes.
+func NewEmployeeFactoryFromFile(path string) (*EmployeeFactory, error) {
+	f := &EmployeeFactory{path: path}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// New returns a copy of the prototype for the role, ready to be given a name.
+func (f *EmployeeFactory) New(role string) (*Employee, error) {
+	f.mu.RLock()
+	prototype, ok := f.roles[role]
+	f.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownRole, role)
+	}
+	return &prototype, nil
+}
+
+// Roles lists the roles currently known, sorted.
+func (f *EmployeeFactory) Roles() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	roles := make([]string, 0, len(f.roles))
+	for role := range f.roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// Reload reads the file again. The new roles replace the old ones all at once, and only if the whole file is valid.
+func (f *EmployeeFactory) Reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	roles, err := decodeRoles(filepath.Ext(f.path), data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.roles = roles
+	f.modTime = info.ModTime()
+	f.fileSize = info.Size()
+	return nil
+}
+
+// Watch checks the file every interval and reloads it when it has changed, until ctx is done.
+// Reload errors are passed to onError, which may be nil.
+func (f *EmployeeFactory) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.reloadIfChanged(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (f *EmployeeFactory) reloadIfChanged() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	f.mu.RLock()
+	changed := !info.ModTime().Equal(f.modTime) || info.Size() != f.fileSize
+	f.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	return f.Reload()
+}
+
+// Every format is first turned into JSON, so that the rules for matching keys to fields are the same for all of them.
+func decodeRoles(ext string, data []byte) (map[string]Employee, error) {
+	var err error
+	switch strings.ToLower(ext) {
+	case ".json":
+	case ".yaml", ".yml":
+		data, err = toJSON(parseYAMLRoles(data))
+	case ".toml":
+		data, err = toJSON(parseTOMLRoles(data))
+	default:
+		return nil, fmt.Errorf("unsupported role file format %q", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	roles := make(map[string]Employee, len(raw))
+	for role, fields := range raw {
+		// A null role would decode into an empty Employee without complaint.
+		if bytes.Equal(bytes.TrimSpace(fields), []byte("null")) {
+			return nil, fmt.Errorf("role %s: null instead of an object", role)
+		}
+		dec := json.NewDecoder(bytes.NewReader(fields))
+		dec.DisallowUnknownFields()
+		var e Employee
+		if err := dec.Decode(&e); err != nil {
+			return nil, fmt.Errorf("role %s: %w", role, err)
+		}
+		roles[role] = e
+	}
+	return roles, nil
+}
+
+func toJSON(roles map[string]map[string]interface{}, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(roles)
+}
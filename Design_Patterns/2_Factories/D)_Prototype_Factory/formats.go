@@ -0,0 +1,198 @@
+package prototypefactory
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+The role files only need one level of tables with scalar values in them, so instead of pulling in full YAML and TOML
+libraries, this file reads just that much of each format. Anything beyond it (lists, nested tables, multi-line strings)
+is reported as an error with its line number rather than being misread.
+*/
+
+// parseYAMLRoles reads roles as unindented "role:" lines, each followed by indented "key: value" lines.
+// All the fields of a role must be indented the same way. A deeper line would be a nested value in YAML,
+// so it is an error rather than another field of the role.
+func parseYAMLRoles(data []byte) (map[string]map[string]interface{}, error) {
+	roles := map[string]map[string]interface{}{}
+	var current map[string]interface{}
+	var fieldIndent, lastField string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := stripComment(scanner.Text())
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+		indented := indent != ""
+		key, value, ok := strings.Cut(strings.TrimSpace(text), ":")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line)
+		}
+
+		if !indented {
+			if value != "" {
+				return nil, fmt.Errorf("line %d: role %s should be followed by indented fields", line, key)
+			}
+			if _, exists := roles[key]; exists {
+				return nil, fmt.Errorf("line %d: role %s defined twice", line, key)
+			}
+			current = map[string]interface{}{}
+			roles[key] = current
+			fieldIndent = ""
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: field %s outside of a role", line, key)
+		}
+		switch {
+		case fieldIndent == "":
+			fieldIndent = indent
+		case indent != fieldIndent && strings.HasPrefix(indent, fieldIndent):
+			return nil, fmt.Errorf("line %d: %s is nested under %s, but nested values are not supported", line, key, lastField)
+		case indent != fieldIndent:
+			return nil, fmt.Errorf("line %d: %s is not indented like the fields above it", line, key)
+		}
+		lastField = key
+		v, err := parseYAMLScalar(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		current[key] = v
+	}
+	return roles, scanner.Err()
+}
+
+// parseTOMLRoles reads roles as "[role]" tables with "key = value" lines in them.
+func parseTOMLRoles(data []byte) (map[string]map[string]interface{}, error) {
+	roles := map[string]map[string]interface{}{}
+	var current map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") || strings.HasPrefix(text, "[[") {
+				return nil, fmt.Errorf("line %d: expected \"[role]\"", line)
+			}
+			role, err := parseTOMLKey(text[1 : len(text)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			if _, exists := roles[role]; exists {
+				return nil, fmt.Errorf("line %d: role %s defined twice", line, role)
+			}
+			current = map[string]interface{}{}
+			roles[role] = current
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("line %d: expected \"key = value\"", line)
+		}
+		key, err := parseTOMLKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: field %s outside of a role", line, key)
+		}
+		v, err := parseScalar(strings.TrimSpace(value), false)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		current[key] = v
+	}
+	return roles, scanner.Err()
+}
+
+// parseTOMLKey reads a single bare or quoted key. In TOML, dev.office and "dev".office are dotted keys,
+// which name a table nested in dev, while "dev.office" is a single key with a dot in it.
+func parseTOMLKey(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, `"`):
+		if key, err := strconv.Unquote(s); err == nil {
+			return key, nil
+		}
+	case strings.HasPrefix(s, "'"):
+		if len(s) >= 2 && strings.IndexByte(s[1:], '\'') == len(s)-2 {
+			return s[1 : len(s)-1], nil
+		}
+	case s != "" && strings.Trim(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_-") == "":
+		return s, nil
+	}
+	if strings.Contains(s, ".") {
+		return "", fmt.Errorf("dotted key %s: nested tables are not supported", s)
+	}
+	return "", fmt.Errorf("invalid key %s", s)
+}
+
+// stripComment removes a # comment, unless the # is inside a quoted string.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseYAMLScalar adds null to the scalars of parseScalar. A null field decodes like a missing one.
+func parseYAMLScalar(s string) (interface{}, error) {
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	}
+	return parseScalar(s, true)
+}
+
+// Both formats agree on quoted strings, numbers and booleans, which is all a role needs.
+// Only YAML also has plain strings, which are not quoted. TOML has neither them nor null.
+func parseScalar(s string, plainStrings bool) (interface{}, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value")
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("bad string %s", s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("bad string %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	case s == "true" || s == "false":
+		return s == "true", nil
+	case strings.ContainsAny(s, "[]{}"):
+		return nil, fmt.Errorf("lists and tables are not supported: %s", s)
+	}
+	if i, err := strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64); err == nil {
+		return f, nil
+	}
+	if !plainStrings {
+		return nil, fmt.Errorf("strings must be quoted: %s", s)
+	}
+	return s, nil
+}
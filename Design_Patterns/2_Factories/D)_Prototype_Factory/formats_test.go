@@ -0,0 +1,73 @@
+package prototypefactory
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeRoles(t *testing.T) {
+	tests := []struct {
+		name, ext, data string
+		want            map[string]Employee
+	}{
+		{"yaml", ".yaml", "dev:\n  position: developer # the usual\n  annual_income: 60_000\n",
+			map[string]Employee{"dev": {Position: "developer", AnnualIncome: 60000}}},
+		{"yaml tabs", ".yml", "dev:\n\tposition: 'developer'\n", map[string]Employee{"dev": {Position: "developer"}}},
+		{"toml", ".toml", "[dev]\nposition = \"developer\"\nannual_income = 60000\n",
+			map[string]Employee{"dev": {Position: "developer", AnnualIncome: 60000}}},
+		{"yaml null", ".yaml", "dev:\n  position: ~\n  annual_income: null\nops:\n  position: \"null\"\n",
+			map[string]Employee{"dev": {}, "ops": {Position: "null"}}},
+		{"toml quoted", ".toml", "[\"dev.office\"]\n\"position\" = 'developer'\n", // A single key that happens to contain a dot
+			map[string]Employee{"dev.office": {Position: "developer"}}},
+		{"json", ".json", `{"dev": {"position": "developer"}}`, map[string]Employee{"dev": {Position: "developer"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRoles(tt.ext, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for role, e := range tt.want {
+				if got[role] != e {
+					t.Errorf("role %s = %+v, want %+v", role, got[role], e)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeRolesErrors(t *testing.T) {
+	tests := []struct {
+		name, ext, data, want string
+	}{
+		{"yaml nested", ".yaml", "dev:\n  position: developer\n    level: senior\n", "line 3: level is nested under position"},
+		{"yaml nested first", ".yaml", "dev:\n  office:\n    city: London\n", "line 2: missing value"},
+		{"yaml dedent", ".yaml", "dev:\n    position: developer\n  annual_income: 1\n", "line 3: annual_income is not indented like"},
+		{"yaml outside", ".yaml", "  position: developer\n", "line 1: field position outside of a role"},
+		{"yaml twice", ".yaml", "dev:\n  position: a\ndev:\n", "line 3: role dev defined twice"},
+		{"yaml unknown field", ".yaml", "dev:\n  salary: 1\n", `role dev: json: unknown field "salary"`},
+		// The fields are valid, so only the table header can be the reason for the error
+		{"toml dotted table", ".toml", "[dev.office]\nposition = \"developer\"\n", "line 1: dotted key dev.office: nested tables are not supported"},
+		{"toml quoted dotted table", ".toml", "[dev]\n[\"dev\".office]\nposition = \"developer\"\n", `line 2: dotted key "dev".office`},
+		{"toml spaced dotted table", ".toml", "[ dev . 'office' ]\nposition = \"developer\"\n", "line 1: dotted key dev . 'office'"},
+		{"toml dotted field", ".toml", "[dev]\noffice.city = \"London\"\n", "line 2: dotted key office.city"},
+		{"toml invalid key", ".toml", "[dev team]\n", "line 1: invalid key dev team"},
+		{"toml plain string", ".toml", "[dev]\nposition = developer\n", "line 2: strings must be quoted: developer"},
+		{"toml null", ".toml", "[dev]\nposition = null\n", "line 2: strings must be quoted: null"},
+		{"toml list", ".toml", "[dev]\nposition = [1]\n", "line 2: lists and tables are not supported"},
+		{"json null", ".json", `{"dev": null}`, "role dev: null instead of an object"},
+		{"json null spaced", ".json", `{"dev":  null }`, "role dev: null instead of an object"},
+		{"unknown format", ".ini", "", `unsupported role file format ".ini"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeRoles(tt.ext, []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,16 @@
 package prototypefactory
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
 
 type Employee struct {
-	Name, Position string
-	AnnualIncome   int
+	Name         string `json:"name"`
+	Position     string `json:"position"`
+	AnnualIncome int    `json:"annual_income"`
 }
 
 const (
@@ -27,4 +33,19 @@ func main() {
 	m := NewEmployee(Manager)
 	m.Name = "Sam"
 	fmt.Println(m)
+
+	// The roles can also come from a file, see config_factory.go
+	factory, err := NewEmployeeFactoryFromFile("roles.yaml")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	go factory.Watch(context.Background(), 5*time.Second, func(err error) { fmt.Println(err) })
+	if d, err := factory.New("developer"); err == nil {
+		d.Name = "Adam"
+		fmt.Println(d)
+	}
+	if _, err := factory.New("astronaut"); errors.Is(err, ErrUnknownRole) {
+		fmt.Println(err)
+	}
 }
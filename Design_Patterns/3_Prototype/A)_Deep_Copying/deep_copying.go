@@ -40,6 +40,7 @@ func main() {
 
 		Now, Jane's address can be customized.
 		However, this approach doesn't scale.
+		The following examples show better ways, up to a DeepCopy that works for any type in E_Reflective_Deep_Copy.
 	*/
 	//---------------------------------------------------------------------------------------------//
 }
@@ -23,12 +23,14 @@ to that slice.
 
 Moreover, we would need to check every single one of the structs to make sure that everyone of the member
 types has a deep copy method.
-
+E_Reflective_Deep_Copy shows a DeepCopy function that works for any type instead.
 */
 func (p *Person) DeepCopy() *Person {
 	q := *p
 	q.Address = p.Address.DeepCopy()
-	copy(p.Friends, q.Friends)
+	// q.Friends still points to the same array as p.Friends, so it needs an array of its own
+	q.Friends = make([]string, len(p.Friends))
+	copy(q.Friends, p.Friends)
 	return &q
 }
 
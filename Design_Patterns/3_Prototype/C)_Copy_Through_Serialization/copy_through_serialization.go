@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+
+	serializationcopier "github.com/Germanchrystan/design_patterns_course/Design_Patterns/3_Prototype/F_Serialization_Copier"
 )
 
 // Adding support for encoding
@@ -30,18 +32,29 @@ type Person struct {
 	Friends []string
 }
 
-func (p *Person) DeepCopy() *Person {
+func (p *Person) DeepCopy() (*Person, error) {
 	b := bytes.Buffer{}     // Creating a buffer
 	e := gob.NewEncoder(&b) // e is going to be an encoder, which takes a pointer to the buffer
-	_ = e.Encode(p)         // Encoding the person instance
-
-	fmt.Println(string(b.Bytes())) // This is just written to take a look into the encoded object
-	// Some of the characters will be buggy, since some of it won't be printable
+	// Encoding the person instance. Encoding can fail, for example on a channel, so the error is not ignored
+	if err := e.Encode(p); err != nil {
+		return nil, err
+	}
 
 	d := gob.NewDecoder(&b)
 	result := Person{}
-	_ = d.Decode(&result) // Decoding into a new instance of Person
-	return &result
+	// Decoding into a new instance of Person
+	if err := d.Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+/*
+The same three steps are needed for every type, so F_Serialization_Copier wraps them into a Copier,
+which can use gob, JSON or a leaner binary codec, and reuses its buffers.
+*/
+func (p *Person) DeepCopyWith(c serializationcopier.Copier) (*Person, error) {
+	return serializationcopier.Clone(c, p)
 }
 
 func main() {
@@ -50,7 +63,11 @@ func main() {
 		&Address{"123 London Rd.", "London", "UK"},
 		[]string{"Chris", "Matt"},
 	}
-	jane := john.DeepCopy()
+	jane, err := john.DeepCopyWith(serializationcopier.Binary)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	jane.Name = "Jane"
 	jane.Address.StreetAddress = "321 Baker St."
 	jane.Friends = append(jane.Friends, "Angela")
@@ -0,0 +1,109 @@
+package main
+
+import (
+	reflectivedeepcopy "github.com/Germanchrystan/design_patterns_course/Design_Patterns/3_Prototype/E_Reflective_Deep_Copy"
+)
+
+/*
+NewOfficeEmployee deep copies the whole prototype for every employee, even though only the name and the suite
+are ever different. The office details are most of an Employee, so thousands of employees of the same office
+end up with thousands of identical copies of them.
+
+Copy-on-write turns it around: the name and the suite are fields of the instance, and every other branch points
+into the prototype stored in the registry. A branch is only copied the first time the instance writes to it,
+and until then, reading goes straight to the prototype.
+This only works because the registry never changes a stored prototype: registering a name again adds a new version.
+
+The benchmarks in copy_on_write_test.go use an office with a hundred contacts and thirty policies. A deep copy of it
+takes hundreds of allocations, while a new copy-on-write instance takes a single small one.
+*/
+
+// Cow holds a value that is shared with a prototype until Mut is called for the first time.
+type Cow[T any] struct {
+	value *T
+	owned bool
+}
+
+// Share wraps a value that nobody changes any more. Every Cow made from it shares it until it is written.
+func Share[T any](v *T) Cow[T] {
+	return Cow[T]{value: v}
+}
+
+// Get is for reading only. Writing through the pointer it returns would change the prototype and every other instance.
+func (c *Cow[T]) Get() *T {
+	return c.value
+}
+
+// Mut copies the shared value the first time it is called, and returns a pointer that can be written to.
+func (c *Cow[T]) Mut() *T {
+	if !c.owned {
+		c.value = reflectivedeepcopy.DeepCopy(c.value)
+		c.owned = true
+	}
+	return c.value
+}
+
+func (c *Cow[T]) Shared() bool {
+	return !c.owned
+}
+
+//---------------------------------------------------------------------------------------------//
+
+// CowEmployee is an Employee whose office and details stay shared with the prototype until they are changed.
+type CowEmployee struct {
+	Name    string
+	Suite   int
+	office  Cow[Address]
+	details Cow[OfficeDetails]
+}
+
+// NewCowOfficeEmployee is NewOfficeEmployee without the copy.
+func NewCowOfficeEmployee(office, name string, suite int) (*CowEmployee, error) {
+	proto, err := offices.latest(office)
+	if err != nil {
+		return nil, err
+	}
+	return &CowEmployee{Name: name, Suite: suite, office: Share(&proto.Office), details: Share(&proto.Details)}, nil
+}
+
+func (e *CowEmployee) Office() Address {
+	office := *e.office.Get()
+	office.Suite = e.Suite
+	return office
+}
+
+// Details is for reading only, like Cow.Get.
+func (e *CowEmployee) Details() *OfficeDetails {
+	return e.details.Get()
+}
+
+func (e *CowEmployee) SetStreetAddress(streetAddress string) {
+	e.office.Mut().StreetAddress = streetAddress
+}
+
+func (e *CowEmployee) SetCity(city string) {
+	e.office.Mut().City = city
+}
+
+func (e *CowEmployee) AddFacility(facility string) {
+	details := e.details.Mut()
+	details.Facilities = append(details.Facilities, facility)
+}
+
+func (e *CowEmployee) SetContact(role, contact string) {
+	details := e.details.Mut()
+	if details.Contacts == nil {
+		details.Contacts = map[string]string{}
+	}
+	details.Contacts[role] = contact
+}
+
+// Shared tells whether the employee still uses every branch of the prototype.
+func (e *CowEmployee) Shared() bool {
+	return e.office.Shared() && e.details.Shared()
+}
+
+// Employee returns an ordinary, independent Employee with the same values.
+func (e *CowEmployee) Employee() *Employee {
+	return &Employee{e.Name, e.Office(), *reflectivedeepcopy.DeepCopy(e.details.Get())}
+}
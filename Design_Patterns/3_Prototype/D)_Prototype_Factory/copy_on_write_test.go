@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	reflectivedeepcopy "github.com/Germanchrystan/design_patterns_course/Design_Patterns/3_Prototype/E_Reflective_Deep_Copy"
+)
+
+var registerHeadquarters sync.Once
+
+// headquarters registers an office with a realistic amount of details: a hundred contacts and thirty policies.
+func headquarters() string {
+	registerHeadquarters.Do(func() {
+		details := OfficeDetails{Contacts: map[string]string{}}
+		for i := 0; i < 20; i++ {
+			details.Facilities = append(details.Facilities, fmt.Sprintf("meeting room %d", i))
+		}
+		for i := 0; i < 100; i++ {
+			details.Contacts[fmt.Sprintf("role %d", i)] = fmt.Sprintf("person%d@example.com", i)
+		}
+		for i := 0; i < 30; i++ {
+			policy := Policy{Title: fmt.Sprintf("Policy %d", i)}
+			for j := 0; j < 10; j++ {
+				policy.Paragraphs = append(policy.Paragraphs, fmt.Sprintf("Paragraph %d of policy %d, which everybody has to read.", j, i))
+			}
+			details.Policies = append(details.Policies, policy)
+		}
+		mustRegister("headquarters", Employee{"", Address{0, "1 Main Sq", "London"}, details})
+	})
+	return "headquarters"
+}
+
+func TestCowEmployeeCopiesOnlyWhatIsWritten(t *testing.T) {
+	office := headquarters()
+	proto, err := offices.Clone(office)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ann, err := NewCowOfficeEmployee(office, "Ann", 101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewCowOfficeEmployee(office, "Bob", 102)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ann.Shared() || ann.Details() != bob.Details() {
+		t.Fatal("new employees do not share the prototype")
+	}
+	if got := ann.Office(); got.Suite != 101 || got.City != "London" {
+		t.Errorf("Office() = %+v", got)
+	}
+
+	ann.SetCity("Leeds")
+	if ann.office.Shared() || !ann.details.Shared() {
+		t.Error("writing the city copied more than the office")
+	}
+	ann.AddFacility("roof garden")
+	ann.SetContact("role 0", "ann@example.com")
+	if ann.details.Shared() || ann.Details() == bob.Details() {
+		t.Error("writing the details did not copy them")
+	}
+	if bob.Office().City != "London" || len(bob.Details().Facilities) != 20 || bob.Details().Contacts["role 0"] != "person0@example.com" {
+		t.Error("writing to one employee changed another")
+	}
+	if unchanged, err := offices.Clone(office); err != nil || !reflect.DeepEqual(unchanged, proto) {
+		t.Errorf("writing to an employee changed the prototype: %v", err)
+	}
+
+	// Employee hands out a copy, so changing it changes nothing else
+	e := bob.Employee()
+	if e.Name != "Bob" || e.Office.Suite != 102 || !reflect.DeepEqual(e.Details, proto.Details) {
+		t.Errorf("Employee() = %v", e)
+	}
+	e.Details.Policies[0].Title = "changed"
+	if bob.Details().Policies[0].Title == "changed" {
+		t.Error("the Employee of a CowEmployee shares its details")
+	}
+
+	if _, err := NewCowOfficeEmployee("nowhere", "Eve", 1); err == nil {
+		t.Error("no error for an office that is not registered")
+	}
+}
+
+//---------------------------------------------------------------------------------------------//
+
+// BenchmarkNewOfficeEmployee is what the factory does without copy-on-write: a binary round trip of the prototype.
+func BenchmarkNewOfficeEmployee(b *testing.B) {
+	office := headquarters()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewOfficeEmployee(office, "Ann", i); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDeepCopyEmployee(b *testing.B) {
+	proto, err := offices.Clone(headquarters())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e := reflectivedeepcopy.DeepCopy(proto)
+		e.Name, e.Office.Suite = "Ann", i
+	}
+}
+
+func BenchmarkNewCowOfficeEmployee(b *testing.B) {
+	office := headquarters()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewCowOfficeEmployee(office, "Ann", i); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Writing the city copies the office branch only, which is still far less than the whole prototype.
+func BenchmarkNewCowOfficeEmployeeAndSetCity(b *testing.B) {
+	office := headquarters()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e, err := NewCowOfficeEmployee(office, "Ann", i)
+		if err != nil {
+			b.Fatal(err)
+		}
+		e.SetCity("Leeds")
+	}
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
+
+	serializationcopier "github.com/Germanchrystan/design_patterns_course/Design_Patterns/3_Prototype/F_Serialization_Copier"
 )
 
 type Address struct {
@@ -12,44 +12,124 @@ type Address struct {
 }
 
 type Employee struct {
-	Name   string
-	Office Address
+	Name    string
+	Office  Address
+	Details OfficeDetails
+}
+
+// OfficeDetails is the same for everybody working at an office, and it is most of an Employee.
+type OfficeDetails struct {
+	Facilities []string
+	Contacts   map[string]string // by role
+	Policies   []Policy
+}
+
+type Policy struct {
+	Title      string
+	Paragraphs []string
+}
+
+// The copy goes through the binary codec of F_Serialization_Copier, which reports errors instead of dropping them.
+func (p *Employee) DeepCopy() (*Employee, error) {
+	return serializationcopier.Clone(serializationcopier.Binary, p)
 }
 
-func (p *Employee) DeepCopy() *Employee {
-	b := bytes.Buffer{}
-	e := gob.NewEncoder(&b)
-	_ = e.Encode(p)
-	d := gob.NewDecoder(&b)
-	result := Employee{}
-	_ = d.Decode(&result)
-	return &result
+// The offices are prototypes in a registry now, see registry.go
+var offices = NewRegistry[Employee](serializationcopier.Binary)
+
+func init() {
+	mustRegister("main", Employee{"", Address{0, "123 East Dr", "London"}, OfficeDetails{Facilities: []string{"canteen", "gym"}}})
+	mustRegister("aux", Employee{"", Address{0, "66 West Dr", "London"}, OfficeDetails{Facilities: []string{"canteen"}}})
 }
 
-var mainOffice = Employee{"", Address{0, "123 East Dr", "London"}}
-var auxOffice = Employee{"", Address{0, "66 West Dr", "London"}}
+// The built-in offices are plain values, so failing to register one is a programming error.
+func mustRegister(name string, proto Employee) {
+	if _, err := offices.Register(name, proto); err != nil {
+		panic(err)
+	}
+}
 
 // Deep Copy and Customization in one factory function
-func NewEmployee(proto *Employee, name string, suite int) *Employee {
-	result := proto.DeepCopy()
+func NewEmployee(proto *Employee, name string, suite int) (*Employee, error) {
+	result, err := proto.DeepCopy()
+	if err != nil {
+		return nil, err
+	}
 	result.Name = name
 	result.Office.Suite = suite
-	return result
+	return result, nil
 }
 
-func NewMainOfficeEmployee(name string, suite int) *Employee {
-	return NewEmployee(&mainOffice, name, suite)
+// NewOfficeEmployee works for any office in the registry, including the ones added at runtime.
+func NewOfficeEmployee(office, name string, suite int) (*Employee, error) {
+	return offices.Clone(office, func(e *Employee) {
+		e.Name = name
+		e.Office.Suite = suite
+	})
 }
 
-func NewAuxOfficeEmployee(name string, suite int) *Employee {
-	return NewEmployee(&auxOffice, name, suite)
+func NewMainOfficeEmployee(name string, suite int) (*Employee, error) {
+	return NewOfficeEmployee("main", name, suite)
+}
+
+func NewAuxOfficeEmployee(name string, suite int) (*Employee, error) {
+	return NewOfficeEmployee("aux", name, suite)
 }
 
 func main() {
-	john := NewMainOfficeEmployee("John", 100)
-	jane := NewAuxOfficeEmployee("Jane", 100)
+	john, err := NewMainOfficeEmployee("John", 100)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	jane, err := NewAuxOfficeEmployee("Jane", 100)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	fmt.Println(john)
 	fmt.Println(jane)
 
+	// A new office, and a new version of an existing one, without any new functions
+	if _, err := offices.Register("remote", Employee{"", Address{0, "1 Cloud Ave", "Anywhere"}, OfficeDetails{}}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if _, err := offices.Register("main", Employee{"", Address{0, "125 East Dr", "London"}, OfficeDetails{Facilities: []string{"canteen", "gym"}}}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := offices.LoadFile("offices.json"); err != nil {
+		fmt.Println(err)
+	}
+	for _, office := range offices.Names() {
+		fmt.Println(office, "version", offices.Latest(office))
+	}
+	ann, err := offices.CloneVersion("main", 1, func(e *Employee) { e.Name = "Ann" })
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	bob, err := NewOfficeEmployee("remote", "Bob", 1)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(ann, bob)
+
+	// Copy-on-write employees share the prototype of their office until they change it, see copy_on_write.go
+	visitor, err := NewCowOfficeEmployee("main", "Visitor", 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	sam, err := NewCowOfficeEmployee("main", "Sam", 204)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(visitor.Shared(), sam.Shared(), visitor.Office(), sam.Office()) // A suite of its own costs no copy
+	sam.AddFacility("bike shed")
+	fmt.Println(sam.Shared(), visitor.Details().Facilities, sam.Details().Facilities)
 }
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	serializationcopier "github.com/Germanchrystan/design_patterns_course/Design_Patterns/3_Prototype/F_Serialization_Copier"
+)
+
+/*
+Every new office used to need a package level prototype and a NewXOfficeEmployee function of its own.
+A registry keeps the prototypes by name instead, so new ones can be added while the program runs, from code or from a file.
+
+Registering a name again does not overwrite it: it adds a new version. Clone uses the latest version,
+and CloneVersion lets code that depends on an older template keep using it.
+The registry stores its own copy of every prototype, so changing a value after registering it changes nothing.
+*/
+
+type Registry[T any] struct {
+	copier serializationcopier.Copier
+
+	mu         sync.RWMutex
+	prototypes map[string][]*T // versions in order, version n is at index n-1
+}
+
+func NewRegistry[T any](copier serializationcopier.Copier) *Registry[T] {
+	return &Registry[T]{copier: copier, prototypes: map[string][]*T{}}
+}
+
+// Register stores a copy of proto as the next version of name, and returns that version number.
+func (r *Registry[T]) Register(name string, proto T) (int, error) {
+	stored, err := serializationcopier.Clone(r.copier, &proto)
+	if err != nil {
+		return 0, fmt.Errorf("registering %s: %w", name, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prototypes[name] = append(r.prototypes[name], stored)
+	return len(r.prototypes[name]), nil
+}
+
+// Clone copies the latest version of name, and then applies the customizers to the copy.
+func (r *Registry[T]) Clone(name string, customizers ...func(*T)) (*T, error) {
+	return r.CloneVersion(name, 0, customizers...)
+}
+
+// CloneVersion is Clone for a given version. Version 0 stands for the latest one.
+func (r *Registry[T]) CloneVersion(name string, version int, customizers ...func(*T)) (*T, error) {
+	r.mu.RLock()
+	versions := r.prototypes[name]
+	r.mu.RUnlock()
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no prototype registered as %q", name)
+	}
+	if version == 0 {
+		version = len(versions)
+	}
+	if version < 0 || version > len(versions) {
+		return nil, fmt.Errorf("prototype %q has no version %d, latest is %d", name, version, len(versions))
+	}
+
+	// Stored prototypes are never changed, so they can be copied without holding the lock.
+	result, err := serializationcopier.Clone(r.copier, versions[version-1])
+	if err != nil {
+		return nil, fmt.Errorf("cloning %s version %d: %w", name, version, err)
+	}
+	for _, customize := range customizers {
+		customize(result)
+	}
+	return result, nil
+}
+
+// Names lists the registered prototypes, sorted.
+func (r *Registry[T]) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.prototypes))
+	for name := range r.prototypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Latest returns the latest version of name, or 0 when nothing is registered under it.
+func (r *Registry[T]) Latest(name string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.prototypes[name])
+}
+
+// latest returns the stored latest version of name itself, not a copy, for copy-on-write. It must never be written to.
+func (r *Registry[T]) latest(name string) (*T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.prototypes[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no prototype registered as %q", name)
+	}
+	return versions[len(versions)-1], nil
+}
+
+/*
+LoadFile registers the prototypes of a JSON file. Each entry becomes the next version of its name,
+in the order of the file:
+
+	[
+		{"name": "main", "prototype": {"Office": {"StreetAddress": "123 East Dr", "City": "London"}}},
+		{"name": "aux", "prototype": {"Office": {"StreetAddress": "66 West Dr", "City": "London"}}}
+	]
+
+Every prototype of the file is copied before any of them is registered, and then they are all registered at once.
+So either the whole file is registered or nothing is, and Clone never sees only part of it.
+*/
+func (r *Registry[T]) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var entries []struct {
+		Name      string `json:"name"`
+		Prototype T      `json:"prototype"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for i, e := range entries {
+		if e.Name == "" {
+			return fmt.Errorf("%s: entry %d has no name", path, i)
+		}
+	}
+	staged := make([]*T, len(entries))
+	for i := range entries {
+		stored, err := serializationcopier.Clone(r.copier, &entries[i].Prototype)
+		if err != nil {
+			return fmt.Errorf("%s: registering %s: %w", path, entries[i].Name, err)
+		}
+		staged[i] = stored
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range entries {
+		r.prototypes[e.Name] = append(r.prototypes[e.Name], staged[i])
+	}
+	return nil
+}
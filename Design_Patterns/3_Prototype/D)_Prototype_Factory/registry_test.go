@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	serializationcopier "github.com/Germanchrystan/design_patterns_course/Design_Patterns/3_Prototype/F_Serialization_Copier"
+)
+
+var errNowhere = errors.New("cannot copy an office in Nowhere")
+
+// pickyCopier is the binary copier, except that it fails for offices in Nowhere.
+type pickyCopier struct{}
+
+func (pickyCopier) Copy(dst, src interface{}) error {
+	if e, ok := src.(*Employee); ok && e.Office.City == "Nowhere" {
+		return errNowhere
+	}
+	return serializationcopier.Binary.Copy(dst, src)
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "offices.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFileRegistersInFileOrder(t *testing.T) {
+	r := NewRegistry[Employee](pickyCopier{})
+	path := writeFile(t, `[
+		{"name": "main", "prototype": {"Office": {"City": "London"}}},
+		{"name": "aux", "prototype": {"Office": {"City": "Leeds"}}},
+		{"name": "main", "prototype": {"Office": {"City": "Paris"}}}
+	]`)
+	if err := r.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"aux", "main"}) || r.Latest("main") != 2 {
+		t.Fatalf("Names() = %q, main has %d versions", names, r.Latest("main"))
+	}
+	first, _ := r.CloneVersion("main", 1)
+	latest, _ := r.Clone("main")
+	if first.Office.City != "London" || latest.Office.City != "Paris" {
+		t.Errorf("main is in %s, then %s", first.Office.City, latest.Office.City)
+	}
+}
+
+func TestLoadFileRegistersAllOrNothing(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"copy fails", `[
+			{"name": "main", "prototype": {"Office": {"City": "London"}}},
+			{"name": "lost", "prototype": {"Office": {"City": "Nowhere"}}}
+		]`, "registering lost: " + errNowhere.Error()},
+		{"no name", `[
+			{"name": "main", "prototype": {"Office": {"City": "London"}}},
+			{"prototype": {"Office": {"City": "Leeds"}}}
+		]`, "entry 1 has no name"},
+		{"bad JSON", `[{"name": "main", "prototype": {"Office": {"City": 1}}}]`, "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		r := NewRegistry[Employee](pickyCopier{})
+		if _, err := r.Register("main", Employee{Office: Address{City: "Rome"}}); err != nil {
+			t.Fatal(err)
+		}
+		err := r.LoadFile(writeFile(t, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: LoadFile() = %v, want an error containing %q", tt.name, err, tt.want)
+		}
+		if names := r.Names(); !reflect.DeepEqual(names, []string{"main"}) || r.Latest("main") != 1 {
+			t.Errorf("%s: a failed load registered something: %q, main has %d versions", tt.name, names, r.Latest("main"))
+		}
+	}
+
+	if err := NewRegistry[Employee](pickyCopier{}).LoadFile(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadFile(missing) = %v, want os.ErrNotExist", err)
+	}
+}
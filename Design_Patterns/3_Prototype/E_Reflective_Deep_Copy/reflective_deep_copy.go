@@ -0,0 +1,183 @@
+package reflectivedeepcopy
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+/*
+	Reflective Deep Copy
+Writing a DeepCopy method for every struct does not scale, and it is easy to get wrong (see the Friends slice
+in B)_Copy_Method). Serialization works for any type, but it skips unexported fields and cannot deal with cycles.
+
+Reflection can walk any value and copy everything it can reach: pointers, slices, maps, arrays, interfaces,
+and unexported fields too. It also remembers what it has already copied, so that:
+  - a cycle (a person who is their own friend's friend) does not recurse forever,
+  - two pointers to the same value in the original point to the same value in the copy.
+
+What is not copied:
+  - channels, functions and unsafe pointers are shared, as there is no meaningful way to copy them,
+  - *time.Location is shared, as locations never change and time.Local is compared by pointer,
+  - sub-slices of the same array are only shared in the copy when they are exactly the same slice,
+  - a pointer into another value of the graph, such as &s.Field or &slice[i], gets a value of its own in the copy
+    instead of pointing into the copy of s or slice. Pointers are matched by their type and address, and finding
+    the value that contains an address would mean collecting every value of the graph before copying any of them.
+*/
+
+// DeepCopy returns a copy of src that shares no memory with it, apart from the exceptions above.
+func DeepCopy[T any](src T) T {
+	c := copier{seen: map[seenKey]reflect.Value{}}
+	from := reflect.ValueOf(&src).Elem()
+	to := reflect.New(from.Type()).Elem()
+	c.copy(to, from)
+	return *to.Addr().Interface().(*T)
+}
+
+// Values are recognised by their type and address, and slices by their length and capacity as well.
+type seenKey struct {
+	typ      reflect.Type
+	addr     uintptr
+	len, cap int
+}
+
+type copier struct {
+	seen map[seenKey]reflect.Value
+}
+
+var locationType = reflect.TypeOf(&time.Location{})
+
+// copy fills dst, which is always addressable and settable, with a deep copy of src.
+func (c *copier) copy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		if src.Type() == locationType {
+			dst.Set(src)
+			return
+		}
+		key := seenKey{typ: src.Type(), addr: src.Pointer()}
+		if copied, ok := c.seen[key]; ok {
+			dst.Set(copied)
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		c.seen[key] = p // Before going deeper, so that a cycle back to here finds it
+		c.copy(p.Elem(), unlock(src.Elem()))
+		dst.Set(p)
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := src.Elem()
+		v := reflect.New(elem.Type()).Elem()
+		c.copy(v, elem)
+		dst.Set(v)
+
+	case reflect.Struct:
+		src = addressable(src)
+		for i := 0; i < src.NumField(); i++ {
+			c.copy(unlock(dst.Field(i)), unlock(src.Field(i)))
+		}
+
+	case reflect.Array:
+		src = addressable(src)
+		for i := 0; i < src.Len(); i++ {
+			c.copy(dst.Index(i), unlock(src.Index(i)))
+		}
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		key := seenKey{typ: src.Type(), addr: src.Pointer(), len: src.Len(), cap: src.Cap()}
+		if copied, ok := c.seen[key]; ok {
+			dst.Set(copied)
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		c.seen[key] = s
+		for i := 0; i < src.Len(); i++ {
+			c.copy(s.Index(i), unlock(src.Index(i)))
+		}
+		dst.Set(s)
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		key := seenKey{typ: src.Type(), addr: src.Pointer()}
+		if copied, ok := c.seen[key]; ok {
+			dst.Set(copied)
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		c.seen[key] = m
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(src.Type().Key()).Elem()
+			c.copy(k, iter.Key())
+			v := reflect.New(src.Type().Elem()).Elem()
+			c.copy(v, iter.Value())
+			m.SetMapIndex(k, v)
+		}
+		dst.Set(m)
+
+	case reflect.Invalid:
+		panic(fmt.Sprintf("reflectivedeepcopy: cannot copy invalid value into %s", dst.Type()))
+
+	default:
+		// Numbers, strings, booleans, and the channels, functions and unsafe pointers that are shared on purpose
+		dst.Set(src)
+	}
+}
+
+// unlock lifts the restriction reflection puts on unexported fields, so they can be read and written like the others.
+// Only addressable values can be unlocked. The others never come from an unexported field, as we make them addressable first.
+func unlock(v reflect.Value) reflect.Value {
+	if !v.CanAddr() || v.CanSet() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// Values held by an interface or a map are not addressable, so their fields are reached through a shallow copy.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	a := reflect.New(v.Type()).Elem()
+	a.Set(v)
+	return a
+}
+
+//---------------------------------------------------------------------------------------------//
+
+type Address struct {
+	StreetAddress, City, Country string
+}
+
+type Person struct {
+	Name    string
+	Address *Address
+	Friends []*Person
+	tags    map[string][]string
+}
+
+func main() {
+	john := &Person{Name: "John", Address: &Address{"123 London Rd", "London", "UK"}, tags: map[string][]string{"likes": {"tea"}}}
+	jane := &Person{Name: "Jane", Address: john.Address, Friends: []*Person{john}}
+	john.Friends = []*Person{jane} // A cycle, and both share the same address
+
+	janeCopy := DeepCopy(jane)
+	janeCopy.Address.StreetAddress = "321 Baker St"
+	janeCopy.Friends[0].tags["likes"][0] = "coffee"
+
+	fmt.Println(jane.Address.StreetAddress, john.tags)                        // Unchanged
+	fmt.Println(janeCopy.Friends[0].Address == janeCopy.Address)              // Still shared, but only within the copy
+	fmt.Println(janeCopy.Friends[0].Friends[0] == janeCopy, janeCopy != jane) // The cycle is kept
+}
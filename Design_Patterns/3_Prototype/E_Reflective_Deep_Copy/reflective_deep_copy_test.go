@@ -0,0 +1,240 @@
+package reflectivedeepcopy
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// record reaches every kind the copier deals with, including through unexported fields and interfaces.
+type record struct {
+	ID       int
+	Name     string
+	Tags     []string
+	Scores   map[string][]int
+	Grid     [2][]byte
+	Extra    interface{}
+	Children []*record
+	notes    map[int]*string
+	secret   []byte
+}
+
+// newRecord builds a random tree of records. The same seed always gives the same tree.
+func newRecord(seed int64) record {
+	return randomRecord(rand.New(rand.NewSource(seed)), 3)
+}
+
+func randomRecord(r *rand.Rand, depth int) record {
+	value := func(v interface{}) {
+		generated, _ := quick.Value(reflect.TypeOf(v).Elem(), r)
+		reflect.ValueOf(v).Elem().Set(generated)
+	}
+	var rec record
+	value(&rec.ID)
+	value(&rec.Name)
+	value(&rec.Tags)
+	value(&rec.Scores)
+	value(&rec.Grid)
+	value(&rec.notes)
+	value(&rec.secret)
+	switch r.Intn(3) {
+	case 1:
+		var numbers []int
+		value(&numbers)
+		rec.Extra = numbers
+	case 2:
+		leaf := randomRecord(r, 0)
+		rec.Extra = &leaf
+	}
+	for i := r.Intn(depth + 1); i > 0; i-- {
+		child := randomRecord(r, depth-1)
+		rec.Children = append(rec.Children, &child)
+	}
+	return rec
+}
+
+// mutate changes every number, string and boolean reachable from v, which must be addressable.
+func mutate(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(v.Uint() + 1)
+	case reflect.String:
+		v.SetString(v.String() + "!")
+	case reflect.Bool:
+		v.SetBool(!v.Bool())
+	case reflect.Ptr:
+		if !v.IsNil() {
+			mutate(v.Elem())
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			elem := reflect.New(v.Elem().Type()).Elem()
+			elem.Set(v.Elem())
+			mutate(elem)
+			v.Set(elem)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			mutate(unlock(v.Field(i)))
+		}
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			mutate(unlock(v.Index(i)))
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			mutate(elem)
+			v.SetMapIndex(key, elem)
+		}
+	}
+}
+
+func TestMutatingTheCopyLeavesTheOriginalAlone(t *testing.T) {
+	property := func(seed int64) bool {
+		original, twin := newRecord(seed), newRecord(seed)
+		copied := DeepCopy(original)
+		if !reflect.DeepEqual(copied, original) {
+			t.Logf("seed %d: the copy differs from the original before any change", seed)
+			return false
+		}
+		mutate(reflect.ValueOf(&copied).Elem())
+		if reflect.DeepEqual(copied, twin) {
+			t.Logf("seed %d: mutate did not change the copy", seed)
+			return false
+		}
+		return reflect.DeepEqual(original, twin)
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+type node struct {
+	ID    int
+	Next  []*node
+	Peer  *node
+	Data  []int
+	attrs map[string]int
+}
+
+// A link joins two nodes: through Next, through Peer, or by giving them the same Data slice or attrs map.
+type link struct {
+	From, To, Kind uint8
+}
+
+func newGraph(size uint8, links []link) []*node {
+	nodes := make([]*node, int(size%8)+1)
+	for i := range nodes {
+		nodes[i] = &node{ID: i, Data: []int{i}, attrs: map[string]int{"id": i}}
+	}
+	for _, l := range links {
+		from, to := nodes[int(l.From)%len(nodes)], nodes[int(l.To)%len(nodes)]
+		switch l.Kind % 4 {
+		case 0:
+			from.Next = append(from.Next, to)
+		case 1:
+			from.Peer = to
+		case 2:
+			from.Data = to.Data
+		case 3:
+			from.attrs = to.attrs
+		}
+	}
+	return nodes
+}
+
+func TestCopyKeepsCyclesAndSharing(t *testing.T) {
+	property := func(size uint8, links []link) bool {
+		original := newGraph(size, links)
+		copied := DeepCopy(original)
+		if len(copied) != len(original) {
+			return false
+		}
+
+		// Both graphs are compared by shape: every node is known by its position
+		originalIndex, copiedIndex := map[*node]int{nil: -1}, map[*node]int{nil: -1}
+		for i := range original {
+			originalIndex[original[i]], copiedIndex[copied[i]] = i, i
+		}
+		pointer := func(v interface{}) uintptr { return reflect.ValueOf(v).Pointer() }
+		for i, c := range copied {
+			o := original[i]
+			if _, shared := originalIndex[c]; shared || pointer(c.Data) == pointer(o.Data) || pointer(c.attrs) == pointer(o.attrs) {
+				t.Logf("node %d of the copy shares memory with the original", i)
+				return false
+			}
+			if copiedIndex[c.Peer] != originalIndex[o.Peer] || len(c.Next) != len(o.Next) {
+				return false
+			}
+			for k := range c.Next {
+				if copiedIndex[c.Next[k]] != originalIndex[o.Next[k]] {
+					return false
+				}
+			}
+			for j := range copied {
+				if (pointer(c.Data) == pointer(copied[j].Data)) != (pointer(o.Data) == pointer(original[j].Data)) ||
+					(pointer(c.attrs) == pointer(copied[j].attrs)) != (pointer(o.attrs) == pointer(original[j].attrs)) {
+					t.Logf("nodes %d and %d do not share the same data in the copy and in the original", i, j)
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// holder has pointers into its own fields, next to pointers to whole values.
+type holder struct {
+	Items  []int
+	Inner  struct{ N int }
+	Item   *int // Into Items
+	Field  *int // Into Inner
+	Same   *int // The same address and type as Item
+	Whole  *holder
+	Whole2 *holder
+}
+
+// DeepCopy does not keep interior pointers: they get values of their own, equal to the original ones.
+// Pointers to the same address with the same type, like Item and Same, still point to the same value.
+func TestInteriorPointersAreCopiedSeparately(t *testing.T) {
+	property := func(items []int, index uint8, n int) bool {
+		if len(items) == 0 {
+			items = []int{0}
+		}
+		h := &holder{Items: items}
+		h.Inner.N = n
+		h.Item = &h.Items[int(index)%len(items)]
+		h.Field = &h.Inner.N
+		h.Same = h.Item
+		h.Whole, h.Whole2 = h, h
+
+		c := DeepCopy(h)
+		if *c.Item != *h.Item || *c.Field != h.Inner.N || c.Same != c.Item || c.Whole != c || c.Whole2 != c {
+			t.Logf("the copy of %+v is %+v", h, c)
+			return false
+		}
+		if c.Item == h.Item || c.Field == h.Field || &c.Items[0] == &h.Items[0] {
+			t.Log("the copy shares memory with the original")
+			return false
+		}
+
+		// Writing through an interior pointer of the copy does not reach the value it pointed into
+		*c.Item, *c.Field = *c.Item+1, *c.Field+1
+		if c.Items[int(index)%len(items)] == *c.Item || c.Inner.N == *c.Field {
+			t.Log("an interior pointer was kept")
+			return false
+		}
+		return h.Items[int(index)%len(items)] == *h.Item && h.Inner.N == *h.Field
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,490 @@
+package serializationcopier
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sync"
+)
+
+/*
+Gob writes a description of every type along with the data, so that any program can read it back.
+A copy is read back by the same program, into the same type, so all of that can be left out.
+
+BinaryCodec writes only the values: integers as varints, floats as their bits, strings and slices with their
+length in front, and a flag for nil pointers, slices and maps. Types that encode themselves, through gob.GobEncoder
+or encoding.BinaryMarshaler like time.Time does, are written the way they choose, and gob's interface wins when a type has both.
+
+A copy that quietly loses data is worse than no copy, so anything that cannot be written is an error, with the path to it:
+unexported fields, channels, functions and interfaces, unless the type encodes itself. Gob drops unexported fields and channels
+without a word. Values nested deeper than maxDepth are reported as an error too, which is what a cycle turns into.
+
+BenchmarkCopy compares the copiers on a large nested value. The binary codec takes about as long as gob, which compiles
+an encoder for every type, but allocates less than half the memory. JSON is the slowest by far.
+*/
+
+type BinaryCodec struct{}
+
+func (BinaryCodec) Name() string { return "binary" }
+
+const maxDepth = 1000
+
+var (
+	errTooDeep    = errors.New("value nested too deeply, probably a cycle")
+	errUnexported = errors.New("unexported field cannot be copied")
+)
+
+func (BinaryCodec) Encode(w io.Writer, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return errors.New("cannot encode nil")
+	}
+	bw := bufio.NewWriter(w)
+	e := binaryEncoder{w: bw}
+	if err := e.encode(rv, 0); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (BinaryCodec) Decode(r io.Reader, v interface{}) error {
+	p := reflect.ValueOf(v)
+	if p.Kind() != reflect.Ptr || p.IsNil() {
+		return fmt.Errorf("destination must be a non-nil pointer, got %T", v)
+	}
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	d := binaryDecoder{r: br}
+	return d.decode(p.Elem(), 0)
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+type marshaling int
+
+const (
+	notMarshaled marshaling = iota
+	gobMarshaled
+	binaryMarshaled
+)
+
+var (
+	gobEncoderType        = reflect.TypeOf((*gob.GobEncoder)(nil)).Elem()
+	gobDecoderType        = reflect.TypeOf((*gob.GobDecoder)(nil)).Elem()
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+// typeInfo is what the codec needs to know about a type. Finding it out is slow compared to writing a number,
+// so it is worked out once for every type.
+type typeInfo struct {
+	marshaling marshaling
+	fields     []int // Struct fields to copy, by index
+	err        error // Why values of this type cannot be copied
+}
+
+var typeInfos sync.Map // reflect.Type -> *typeInfo
+
+func infoOf(t reflect.Type) *typeInfo {
+	if info, ok := typeInfos.Load(t); ok {
+		return info.(*typeInfo)
+	}
+	info := &typeInfo{marshaling: marshalingOf(t)}
+	if t.Kind() == reflect.Struct && info.marshaling == notMarshaled {
+		info.fields, info.err = fieldsOf(t)
+	}
+	typeInfos.Store(t, info)
+	return info
+}
+
+// marshalingOf tells whether t encodes itself. It has to decode itself as well, or the copy could not be read back.
+// Pointers are left to the codec, so that a nil pointer is still written as nil.
+func marshalingOf(t reflect.Type) marshaling {
+	if t.Kind() == reflect.Ptr {
+		return notMarshaled
+	}
+	p := reflect.PtrTo(t) // Its methods include those of t
+	switch {
+	case p.Implements(gobEncoderType) && p.Implements(gobDecoderType):
+		return gobMarshaled
+	case p.Implements(binaryMarshalerType) && p.Implements(binaryUnmarshalerType):
+		return binaryMarshaled
+	}
+	return notMarshaled
+}
+
+// Every field is copied, or the copy fails. Blank fields hold nothing, so they are the only ones left out.
+func fieldsOf(t reflect.Type) ([]int, error) {
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Name == "_" {
+			continue
+		}
+		if !f.IsExported() {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.Name, errUnexported)
+		}
+		fields = append(fields, i)
+	}
+	return fields, nil
+}
+
+// Methods with a pointer receiver need an addressable value, which a value held by a map or an interface is not.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	a := reflect.New(v.Type()).Elem()
+	a.Set(v)
+	return a
+}
+
+//---------------------------------------------------------------------------------------------//
+
+type binaryEncoder struct {
+	w       *bufio.Writer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (e *binaryEncoder) uvarint(x uint64) error {
+	n := binary.PutUvarint(e.scratch[:], x)
+	_, err := e.w.Write(e.scratch[:n])
+	return err
+}
+
+func (e *binaryEncoder) varint(x int64) error {
+	n := binary.PutVarint(e.scratch[:], x)
+	_, err := e.w.Write(e.scratch[:n])
+	return err
+}
+
+func (e *binaryEncoder) float(f float64) error {
+	binary.LittleEndian.PutUint64(e.scratch[:8], math.Float64bits(f))
+	_, err := e.w.Write(e.scratch[:8])
+	return err
+}
+
+// Nil slices and maps are written as a length of zero, and everything else as its length plus one.
+func (e *binaryEncoder) length(v reflect.Value) error {
+	if v.IsNil() {
+		return e.uvarint(0)
+	}
+	return e.uvarint(uint64(v.Len()) + 1)
+}
+
+func (e *binaryEncoder) bytes(b []byte) error {
+	if err := e.uvarint(uint64(len(b))); err != nil {
+		return err
+	}
+	_, err := e.w.Write(b)
+	return err
+}
+
+func (e *binaryEncoder) marshal(v reflect.Value, m marshaling) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch p := addressable(v).Addr().Interface(); m {
+	case gobMarshaled:
+		data, err = p.(gob.GobEncoder).GobEncode()
+	case binaryMarshaled:
+		data, err = p.(encoding.BinaryMarshaler).MarshalBinary()
+	}
+	if err != nil {
+		return err
+	}
+	return e.bytes(data)
+}
+
+func (e *binaryEncoder) encode(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return errTooDeep
+	}
+	info := infoOf(v.Type())
+	if info.marshaling != notMarshaled {
+		return e.marshal(v, info.marshaling)
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return e.w.WriteByte(1)
+		}
+		return e.w.WriteByte(0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.varint(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return e.uvarint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return e.float(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		if err := e.float(real(v.Complex())); err != nil {
+			return err
+		}
+		return e.float(imag(v.Complex()))
+	case reflect.String:
+		if err := e.uvarint(uint64(v.Len())); err != nil {
+			return err
+		}
+		_, err := e.w.WriteString(v.String())
+		return err
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return e.w.WriteByte(0)
+		}
+		if err := e.w.WriteByte(1); err != nil {
+			return err
+		}
+		return e.encode(v.Elem(), depth+1)
+
+	case reflect.Slice:
+		if err := e.length(v); err != nil {
+			return err
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			_, err := e.w.Write(v.Bytes())
+			return err
+		}
+		return e.elements(v, depth)
+	case reflect.Array:
+		return e.elements(v, depth)
+
+	case reflect.Map:
+		if err := e.length(v); err != nil {
+			return err
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := e.encode(iter.Key(), depth+1); err != nil {
+				return err
+			}
+			if err := e.encode(iter.Value(), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		if info.err != nil {
+			return info.err
+		}
+		for _, i := range info.fields {
+			if err := e.encode(v.Field(i), depth+1); err != nil {
+				return fieldError(v.Type(), i, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot encode %s", v.Type())
+}
+
+// The path to a field that cannot be encoded is helpful, but a cycle would repeat the same path a thousand times.
+func fieldError(t reflect.Type, i int, err error) error {
+	if errors.Is(err, errTooDeep) {
+		return err
+	}
+	return fmt.Errorf("%s.%s: %w", t.Name(), t.Field(i).Name, err)
+}
+
+func (e *binaryEncoder) elements(v reflect.Value, depth int) error {
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//---------------------------------------------------------------------------------------------//
+
+type binaryDecoder struct {
+	r       byteReader
+	scratch [8]byte
+	buf     []byte
+}
+
+func (d *binaryDecoder) float() (float64, error) {
+	if _, err := io.ReadFull(d.r, d.scratch[:]); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(d.scratch[:])), nil
+}
+
+// A corrupt length could ask for an enormous allocation, so lengths are checked against a sane limit first.
+const maxLength = 1 << 31
+
+func (d *binaryDecoder) length() (n int, isNil bool, err error) {
+	l, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return 0, false, err
+	}
+	if l == 0 {
+		return 0, true, nil
+	}
+	if l-1 > maxLength {
+		return 0, false, fmt.Errorf("length %d too large", l-1)
+	}
+	return int(l - 1), false, nil
+}
+
+// bytes reads a length and that many bytes into buf, which it grows as needed, and returns them.
+func (d *binaryDecoder) bytes(buf []byte) ([]byte, error) {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxLength {
+		return nil, fmt.Errorf("length %d too large", n)
+	}
+	if uint64(cap(buf)) < n {
+		buf = make([]byte, n)
+	}
+	buf = buf[:n]
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// The destination of decode is always addressable, so the pointer methods can be called directly.
+func (d *binaryDecoder) unmarshal(v reflect.Value, m marshaling) error {
+	data, err := d.bytes(nil)
+	if err != nil {
+		return err
+	}
+	switch p := v.Addr().Interface(); m {
+	case gobMarshaled:
+		return p.(gob.GobDecoder).GobDecode(data)
+	case binaryMarshaled:
+		return p.(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	}
+	return nil
+}
+
+func (d *binaryDecoder) decode(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return errTooDeep
+	}
+	info := infoOf(v.Type())
+	if info.marshaling != notMarshaled {
+		return d.unmarshal(v, info.marshaling)
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := d.r.ReadByte()
+		v.SetBool(b != 0)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := binary.ReadVarint(d.r)
+		v.SetInt(x)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x, err := binary.ReadUvarint(d.r)
+		v.SetUint(x)
+		return err
+	case reflect.Float32, reflect.Float64:
+		f, err := d.float()
+		v.SetFloat(f)
+		return err
+	case reflect.Complex64, reflect.Complex128:
+		re, err := d.float()
+		if err != nil {
+			return err
+		}
+		im, err := d.float()
+		v.SetComplex(complex(re, im))
+		return err
+	case reflect.String:
+		// SetString copies the bytes, so the same buffer serves every string
+		b, err := d.bytes(d.buf)
+		if err != nil {
+			return err
+		}
+		d.buf = b
+		v.SetString(string(b))
+		return nil
+
+	case reflect.Ptr:
+		flag, err := d.r.ReadByte()
+		if err != nil || flag == 0 {
+			return err
+		}
+		p := reflect.New(v.Type().Elem())
+		if err := d.decode(p.Elem(), depth+1); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+
+	case reflect.Slice:
+		n, isNil, err := d.length()
+		if err != nil || isNil {
+			return err
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, n)
+			if _, err := io.ReadFull(d.r, b); err != nil {
+				return err
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		return d.elements(v, depth)
+	case reflect.Array:
+		return d.elements(v, depth)
+
+	case reflect.Map:
+		n, isNil, err := d.length()
+		if err != nil || isNil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(v.Type(), n)
+		for i := 0; i < n; i++ {
+			k := reflect.New(v.Type().Key()).Elem()
+			if err := d.decode(k, depth+1); err != nil {
+				return err
+			}
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(e, depth+1); err != nil {
+				return err
+			}
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+		return nil
+
+	case reflect.Struct:
+		if info.err != nil {
+			return info.err
+		}
+		for _, i := range info.fields {
+			if err := d.decode(v.Field(i), depth+1); err != nil {
+				return fieldError(v.Type(), i, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot decode %s", v.Type())
+}
+
+func (d *binaryDecoder) elements(v reflect.Value, depth int) error {
+	for i := 0; i < v.Len(); i++ {
+		if err := d.decode(v.Index(i), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package serializationcopier
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	reflectivedeepcopy "github.com/Germanchrystan/design_patterns_course/Design_Patterns/3_Prototype/E_Reflective_Deep_Copy"
+)
+
+/*
+	Serialization Copier
+Copy through serialization is the same three steps every time: encode the value into a buffer,
+decode the buffer into a new value, and check that neither step failed. The Copier interface captures that,
+and the encoding is left to a Codec, so gob, JSON and the binary codec of binary_codec.go are interchangeable.
+
+The buffers are reused through a pool, as making many copies would otherwise allocate a new buffer for each one.
+*/
+
+// Copier copies src into dst. The dst argument must be a pointer to a value of the same type as src.
+type Copier interface {
+	Copy(dst, src interface{}) error
+}
+
+// Clone is the typed way of using a Copier.
+func Clone[T any](c Copier, src T) (T, error) {
+	var dst T
+	err := c.Copy(&dst, src)
+	return dst, err
+}
+
+type Codec interface {
+	Name() string
+	Encode(w io.Writer, v interface{}) error
+	Decode(r io.Reader, v interface{}) error
+}
+
+type SerializationCopier struct {
+	Codec Codec
+}
+
+func New(codec Codec) *SerializationCopier {
+	return &SerializationCopier{codec}
+}
+
+// Buffers that grew very large are not kept, so that one huge copy does not pin that memory forever.
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func (c *SerializationCopier) Copy(dst, src interface{}) error {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			buf.Reset()
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if err := c.Codec.Encode(buf, src); err != nil {
+		return fmt.Errorf("%s copy: encoding %T: %w", c.Codec.Name(), src, err)
+	}
+	if err := c.Codec.Decode(buf, dst); err != nil {
+		return fmt.Errorf("%s copy: decoding into %T: %w", c.Codec.Name(), dst, err)
+	}
+	return nil
+}
+
+//---------------------------------------------------------------------------------------------//
+
+// Gob copies exported fields only, and cannot copy values with cycles.
+type GobCodec struct{}
+
+func (GobCodec) Name() string { return "gob" }
+
+func (GobCodec) Encode(w io.Writer, v interface{}) error {
+	return gob.NewEncoder(w).Encode(v)
+}
+
+func (GobCodec) Decode(r io.Reader, v interface{}) error {
+	return gob.NewDecoder(r).Decode(v)
+}
+
+// JSON is the slowest, but it honours json tags and custom marshalers, which is sometimes exactly what is wanted.
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string { return "json" }
+
+func (JSONCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (JSONCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+var (
+	Gob    = New(GobCodec{})
+	JSON   = New(JSONCodec{})
+	Binary = New(BinaryCodec{})
+)
+
+//---------------------------------------------------------------------------------------------//
+
+// ReflectCopier uses the reflective deep copy, so the copiers can be swapped and compared with each other.
+type ReflectCopier struct{}
+
+var Reflect = ReflectCopier{}
+
+func (ReflectCopier) Copy(dst, src interface{}) error {
+	d := reflect.ValueOf(dst)
+	if d.Kind() != reflect.Ptr || d.IsNil() {
+		return fmt.Errorf("reflect copy: destination must be a non-nil pointer, got %T", dst)
+	}
+	copied := reflect.ValueOf(reflectivedeepcopy.DeepCopy(src))
+	if !copied.IsValid() {
+		d.Elem().Set(reflect.Zero(d.Elem().Type()))
+		return nil
+	}
+	if !copied.Type().AssignableTo(d.Elem().Type()) {
+		return fmt.Errorf("reflect copy: cannot copy %s into %s", copied.Type(), d.Elem().Type())
+	}
+	d.Elem().Set(copied)
+	return nil
+}
+
+//---------------------------------------------------------------------------------------------//
+
+type Address struct {
+	StreetAddress, City, Country string
+}
+
+type Person struct {
+	Name    string
+	Address *Address
+	Friends []string
+}
+
+func main() {
+	john := &Person{"John", &Address{"123 London Rd.", "London", "UK"}, []string{"Chris", "Matt"}}
+
+	for _, c := range []Copier{Gob, JSON, Binary, Reflect} {
+		jane, err := Clone(c, john)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		jane.Name = "Jane"
+		jane.Address.StreetAddress = "321 Baker St."
+		jane.Friends[0] = "Angela"
+		fmt.Println(john, john.Address, jane, jane.Address)
+	}
+
+	// Errors are reported instead of being ignored
+	type node struct{ Next *node }
+	loop := &node{}
+	loop.Next = loop
+	if _, err := Clone(Binary, loop); err != nil {
+		fmt.Println(err)
+	}
+}
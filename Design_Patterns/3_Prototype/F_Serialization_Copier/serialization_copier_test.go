@@ -0,0 +1,197 @@
+package serializationcopier
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var copiers = []struct {
+	name   string
+	copier Copier
+}{
+	{"gob", Gob},
+	{"json", JSON},
+	{"binary", Binary},
+	{"reflect", Reflect},
+}
+
+func TestCopiersAgree(t *testing.T) {
+	original := newCatalog(20, 3)
+	for _, c := range copiers {
+		copied, err := Clone(c.copier, original)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(copied, original) {
+			t.Errorf("%s: the copy differs from the original", c.name)
+		}
+		copied.Orders[0].Lines[0].Notes[0] = "changed"
+		if original.Orders[0].Lines[0].Notes[0] == "changed" {
+			t.Errorf("%s: the copy shares memory with the original", c.name)
+		}
+	}
+}
+
+type meeting struct {
+	Title    string
+	Start    time.Time
+	End      *time.Time
+	Rooms    map[string]celsius
+	Reminder []time.Time
+}
+
+// celsius has nothing but an unexported field, and is copied anyway because it encodes itself.
+// Its methods have pointer receivers, so a value held by a map has to be made addressable first.
+type celsius struct {
+	degrees float64
+}
+
+func (c *celsius) MarshalBinary() ([]byte, error) {
+	return []byte(fmt.Sprint(c.degrees)), nil
+}
+
+func (c *celsius) UnmarshalBinary(data []byte) error {
+	_, err := fmt.Sscan(string(data), &c.degrees)
+	return err
+}
+
+func TestBinaryUsesMarshalers(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	end := start.Add(time.Hour)
+	original := meeting{
+		Title:    "Planning",
+		Start:    start,
+		End:      &end,
+		Rooms:    map[string]celsius{"Blue": {21.5}, "Red": {-math.Pi}},
+		Reminder: []time.Time{start.Add(-time.Hour)},
+	}
+
+	copied, err := Clone(Binary, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !copied.Start.Equal(start) || !copied.End.Equal(end) || !copied.Reminder[0].Equal(original.Reminder[0]) {
+		t.Errorf("times changed: got %v, %v, %v", copied.Start, copied.End, copied.Reminder)
+	}
+	if _, offset := copied.Start.Zone(); offset != 3600 {
+		t.Errorf("time zone offset %d, want 3600", offset)
+	}
+	if !reflect.DeepEqual(copied.Rooms, original.Rooms) {
+		t.Errorf("rooms: got %v, want %v", copied.Rooms, original.Rooms)
+	}
+}
+
+func TestBinaryRejectsWhatItCannotCopy(t *testing.T) {
+	type secret struct {
+		name string
+	}
+	type person struct {
+		Name string
+		tags []string
+	}
+	type worker struct {
+		Name string
+		Jobs chan int
+	}
+	type anything struct {
+		Value interface{}
+	}
+	type padded struct {
+		A int
+		_ [4]byte
+		B int
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+		is    error
+	}{
+		{"only unexported fields", secret{"x"}, "secret.name", errUnexported},
+		{"some unexported fields", &person{"Ann", []string{"admin"}}, "person.tags", errUnexported},
+		{"nested", map[string][]person{"a": {{Name: "Ann"}}}, "person.tags", errUnexported},
+		{"channel", worker{Name: "Bob"}, "worker.Jobs: cannot encode chan int", nil},
+		{"interface", anything{1}, "anything.Value: cannot encode interface {}", nil},
+	}
+	for _, tt := range tests {
+		err := Binary.Copy(reflect.New(reflect.TypeOf(tt.value)).Interface(), tt.value)
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q does not mention %q", tt.name, err, tt.want)
+		}
+		if tt.is != nil && !errors.Is(err, tt.is) {
+			t.Errorf("%s: error %q is not %q", tt.name, err, tt.is)
+		}
+	}
+
+	// Blank fields hold nothing, so they are not a reason to fail
+	if copied, err := Clone(Binary, padded{A: 1, B: 2}); err != nil || copied.A != 1 || copied.B != 2 {
+		t.Errorf("padded: got %+v, %v", copied, err)
+	}
+}
+
+//---------------------------------------------------------------------------------------------//
+
+type catalog struct {
+	Name   string
+	Orders []order
+	Index  map[string][]int
+}
+
+type order struct {
+	ID       int
+	Customer string
+	Shipping *Address
+	Lines    []orderLine
+	Tags     map[string]string
+}
+
+type orderLine struct {
+	SKU      string
+	Quantity int
+	Price    float64
+	Notes    []string
+}
+
+func newCatalog(orders, lines int) *catalog {
+	c := &catalog{Name: "spring", Index: map[string][]int{}}
+	for i := 0; i < orders; i++ {
+		o := order{
+			ID:       i,
+			Customer: fmt.Sprintf("customer %d", i%97),
+			Shipping: &Address{fmt.Sprintf("%d High St", i), "London", "UK"},
+			Tags:     map[string]string{"channel": "web", "priority": fmt.Sprint(i % 3)},
+		}
+		for j := 0; j < lines; j++ {
+			sku := fmt.Sprintf("SKU-%04d", (i*lines+j)%500)
+			o.Lines = append(o.Lines, orderLine{sku, j + 1, float64(i*j) / 7, []string{"gift wrap", "fragile"}})
+			c.Index[sku] = append(c.Index[sku], i)
+		}
+		c.Orders = append(c.Orders, o)
+	}
+	return c
+}
+
+// BenchmarkCopy copies a thousand orders of ten lines each with every copier.
+func BenchmarkCopy(b *testing.B) {
+	original := newCatalog(1000, 10)
+	for _, c := range copiers {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Clone(c.copier, original); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
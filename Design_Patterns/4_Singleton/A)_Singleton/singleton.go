@@ -1,12 +1,11 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strconv"
 	"sync"
+
+	datasource "github.com/Germanchrystan/design_patterns_course/Design_Patterns/4_Singleton/D_Data_Source"
 )
 
 type singletonDatabase struct {
@@ -37,54 +36,66 @@ var once sync.Once
 	This can not be guaranteed by the "init" package level function, but it can be done
 	using things like sync.Once inside our own function
 
-
 */
 var instance *singletonDatabase
 
+/*
+	If loading the data fails, there is no database to hand out. Instead of an empty one, which would
+	answer 0 to every question, we keep the error and return it on every call.
+*/
+var initErr error
+
+/*
+	Where the data comes from is configurable, either through the environment (CAPITALS_FILE and CAPITALS_FORMAT)
+	or by calling ConfigureDatabase. Since the database is only loaded once, it has to be called before the first
+	call to GetSingletonDatabase. After that, it returns ErrAlreadyLoaded instead of changing options nobody reads.
+	Both can be called from any goroutine, so the options are guarded by a mutex.
+*/
+var (
+	optionsMu sync.Mutex
+	options   datasource.Options
+	loaded    bool
+)
+
+var ErrAlreadyLoaded = errors.New("the database is already loaded, it is too late to configure it")
+
+func ConfigureDatabase(opts datasource.Options) error {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+	if loaded {
+		return ErrAlreadyLoaded
+	}
+	options = opts
+	return nil
+}
+
 /*
 	Then we create a function that will return the only instance
 */
-func GetSingletonDatabase() *singletonDatabase {
+func GetSingletonDatabase() (*singletonDatabase, error) {
 	once.Do(func() {
-		caps, err := readData(".\\capitals.txt")
-		db := singletonDatabase{caps}
-		if err == nil {
-			db.capitals = caps
+		optionsMu.Lock()
+		loaded = true
+		opts := options
+		optionsMu.Unlock()
+
+		caps, err := datasource.Load(opts)
+		if err != nil {
+			initErr = fmt.Errorf("loading capitals: %w", err)
+			return
 		}
-		instance = &db
+		instance = &singletonDatabase{caps}
 	})
-	return instance
+	return instance, initErr
 }
 
 func main() {
-	db := GetSingletonDatabase()
+	db, err := GetSingletonDatabase()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	pop := db.GetPopulation("Seoul")
 	fmt.Println("Pop of Seoul = ", pop)
 }
 
-func readData(path string) (map[string]int, error) {
-	ex, err := os.Executable()
-	if err != nil {
-		panic(err)
-	}
-	exPath := filepath.Dir(ex)
-
-	file, err := os.Open(exPath + path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
-
-	result := map[string]int{}
-
-	for scanner.Scan() {
-		k := scanner.Text()
-		scanner.Scan()
-		v, _ := strconv.Atoi(scanner.Text())
-		result[k] = v
-	}
-	return result, nil
-}
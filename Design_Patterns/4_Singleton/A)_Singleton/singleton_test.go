@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	datasource "github.com/Germanchrystan/design_patterns_course/Design_Patterns/4_Singleton/D_Data_Source"
+)
+
+// The singleton can only be loaded once per process, so a single test covers configuring it before and after.
+// Run it with -race: configuring and loading at the same time must not race.
+func TestConfigureDatabaseOnlyBeforeTheFirstLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capitals.csv")
+	if err := os.WriteFile(path, []byte("name,population\nSeoul,1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureDatabase(datasource.Options{Path: path}); err != nil {
+		t.Fatalf("ConfigureDatabase before the first load: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			ConfigureDatabase(datasource.Options{Path: path, Format: datasource.CSV})
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := GetSingletonDatabase(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	db, err := GetSingletonDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.GetPopulation("Seoul"); got != 1 {
+		t.Errorf("GetPopulation(Seoul) = %d, want the 1 of the configured file", got)
+	}
+	if err := ConfigureDatabase(datasource.Options{Path: "elsewhere.csv"}); !errors.Is(err, ErrAlreadyLoaded) {
+		t.Errorf("ConfigureDatabase after the first load = %v, want ErrAlreadyLoaded", err)
+	}
+}
@@ -1,12 +1,10 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strconv"
 	"sync"
+
+	datasource "github.com/Germanchrystan/design_patterns_course/Design_Patterns/4_Singleton/D_Data_Source"
 )
 
 /*
@@ -27,57 +25,35 @@ func (db *singletonDatabase) GetPopulation(name string) int {
 
 var once sync.Once
 var instance *singletonDatabase
+var initErr error
 
-func GetSingletonDatabase() *singletonDatabase {
+func GetSingletonDatabase() (*singletonDatabase, error) {
 	once.Do(func() {
-		caps, err := readData(".\\capitals.txt")
-		db := singletonDatabase{caps}
-		if err == nil {
-			db.capitals = caps
+		caps, err := datasource.Load(datasource.Options{})
+		if err != nil {
+			initErr = fmt.Errorf("loading capitals: %w", err)
+			return
 		}
-		instance = &db
+		instance = &singletonDatabase{caps}
 	})
-	return instance
-}
-
-func readData(path string) (map[string]int, error) {
-	ex, err := os.Executable()
-	if err != nil {
-		panic(err)
-	}
-	exPath := filepath.Dir(ex)
-
-	file, err := os.Open(exPath + path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
-
-	result := map[string]int{}
-
-	for scanner.Scan() {
-		k := scanner.Text()
-		scanner.Scan()
-		v, _ := strconv.Atoi(scanner.Text())
-		result[k] = v
-	}
-	return result, nil
+	return instance, initErr
 }
 
 /*
 	Now, imagine you now want to get the total population of several cities.
 
 */
-func GetTotalPopulation(cities []string) int {
+func GetTotalPopulation(cities []string) (int, error) {
+	db, err := GetSingletonDatabase()
+	if err != nil {
+		return 0, err
+	}
 	result := 0
 	for _, city := range cities {
-		result += GetSingletonDatabase().GetPopulation(city)
+		result += db.GetPopulation(city)
 		//         DIP violation
 	}
-	return result
+	return result, nil
 }
 
 /*
@@ -95,7 +71,11 @@ func GetTotalPopulation(cities []string) int {
 
 func main() {
 	cities := []string{"Seoul", "Mexico City"}
-	tp := GetTotalPopulation(cities)
+	tp, err := GetTotalPopulation(cities)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	// Testing using real life database
 	// What would happen if someone updated the numbers on the database?
 	ok := tp == (17500000 + 17400000)
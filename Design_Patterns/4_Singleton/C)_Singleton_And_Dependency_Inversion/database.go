@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+	GetPopulation used to return 0 for a city it did not know, so "unknown" and "nobody lives there"
+	looked the same. The repository below tells them apart, and answers the questions callers
+	actually ask: every city, the cities starting with a prefix, and the largest ones.
+
+	Every method can fail, because the data might live in a file or in a SQL database.
+	There are three implementations:
+	- MemoryDatabase keeps a map, like the singleton always did.
+	- FileDatabase is an embedded key-value store, kept in a single file (file_database.go).
+	- SQLDatabase keeps the cities in a table of any database/sql database (sql_database.go).
+*/
+
+type City struct {
+	Name       string
+	Population int
+}
+
+type Database interface {
+	// GetPopulation reports false when the city is unknown.
+	GetPopulation(name string) (int, bool, error)
+	// Cities returns every city, sorted by name.
+	Cities() ([]City, error)
+	// SearchPrefix returns the cities whose name starts with prefix, sorted by name. It is case sensitive.
+	SearchPrefix(prefix string) ([]City, error)
+	// TopN returns the n largest cities, largest first. Cities of the same size are sorted by name.
+	TopN(n int) ([]City, error)
+}
+
+var ErrUnknownCity = errors.New("unknown city")
+
+// PopulationCounter is implemented by a Database that can add the populations up itself. GetTotalPopulation leaves
+// the work to it: SQLDatabase does it in one query, FileDatabase under a single lock.
+// MemoryDatabase does not, because DummyDatabase embeds it and has to see every lookup.
+type PopulationCounter interface {
+	// TotalPopulation counts a city as often as it is listed, and fails with ErrUnknownCity for the first unknown one.
+	TotalPopulation(cities []string) (int, error)
+}
+
+// GetTotalPopulation adds up the population of the cities, and fails on the first one the database does not know.
+func GetTotalPopulation(db Database, cities []string) (int, error) {
+	if counter, ok := db.(PopulationCounter); ok {
+		return counter.TotalPopulation(cities)
+	}
+	return sumPopulations(db.GetPopulation, cities)
+}
+
+func sumPopulations(getPopulation func(string) (int, bool, error), cities []string) (int, error) {
+	result := 0
+	for _, city := range cities {
+		population, ok, err := getPopulation(city)
+		//                     Using interface instead of singleton struct method
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return 0, fmt.Errorf("%w %q", ErrUnknownCity, city)
+		}
+		result += population
+	}
+	return result, nil
+}
+
+//---------------------------------------------------------------------------------------------//
+
+type MemoryDatabase struct {
+	capitals map[string]int
+}
+
+// NewMemoryDatabase keeps a copy of the map, so changing it later does not change the database.
+func NewMemoryDatabase(capitals map[string]int) *MemoryDatabase {
+	db := &MemoryDatabase{capitals: make(map[string]int, len(capitals))}
+	for name, population := range capitals {
+		db.capitals[name] = population
+	}
+	return db
+}
+
+func (db *MemoryDatabase) GetPopulation(name string) (int, bool, error) {
+	population, ok := db.capitals[name]
+	return population, ok, nil
+}
+
+func (db *MemoryDatabase) Cities() ([]City, error) {
+	return db.filter(func(string) bool { return true }), nil
+}
+
+func (db *MemoryDatabase) SearchPrefix(prefix string) ([]City, error) {
+	return db.filter(func(name string) bool { return strings.HasPrefix(name, prefix) }), nil
+}
+
+func (db *MemoryDatabase) TopN(n int) ([]City, error) {
+	return topN(db.filter(func(string) bool { return true }), n), nil
+}
+
+func (db *MemoryDatabase) filter(keep func(name string) bool) []City {
+	cities := []City{}
+	for name, population := range db.capitals {
+		if keep(name) {
+			cities = append(cities, City{name, population})
+		}
+	}
+	sortByName(cities)
+	return cities
+}
+
+func sortByName(cities []City) {
+	sort.Slice(cities, func(i, j int) bool { return cities[i].Name < cities[j].Name })
+}
+
+func topN(cities []City, n int) []City {
+	sort.SliceStable(cities, func(i, j int) bool { return cities[i].Population > cities[j].Population })
+	if n < 0 {
+		n = 0
+	}
+	if n < len(cities) {
+		cities = cities[:n]
+	}
+	return cities
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+	FileDatabase is a small embedded key-value store. Every change is appended to a single file as one line:
+		"Seoul" 17500000
+	and a deletion is written as the name alone. Opening the file replays those lines to rebuild the index in memory,
+	so reads never touch the disk.
+	Every line ends with a new line, written together with the record, so a crash in the middle of a write leaves
+	a last line without one. That change never returned from Put or Delete, so OpenFileDatabase drops it and
+	truncates the file back to the last complete line. A complete line that cannot be parsed is real damage,
+	which OpenFileDatabase reports with its line number instead of guessing what was meant.
+	Since the file only grows, Compact rewrites it with one line per city.
+
+	Names are quoted with strconv.Quote, so they may contain spaces, quotes and even new lines.
+*/
+
+type FileDatabase struct {
+	mu    sync.RWMutex
+	path  string
+	file  *os.File
+	index *MemoryDatabase
+}
+
+// OpenFileDatabase opens the store at path, creating an empty one when the file does not exist.
+func OpenFileDatabase(path string) (*FileDatabase, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	db := &FileDatabase{path: path, file: file, index: NewMemoryDatabase(nil)}
+	if err := db.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *FileDatabase) replay() error {
+	r := bufio.NewReader(db.file)
+	var complete int64 // The size of the complete lines read so far
+	for line := 1; ; line++ {
+		text, err := r.ReadString('\n')
+		if err == io.EOF {
+			if text == "" {
+				return nil
+			}
+			// A write that was cut short
+			if err := db.file.Truncate(complete); err != nil {
+				return err
+			}
+			return db.file.Sync()
+		}
+		if err != nil {
+			return err
+		}
+		complete += int64(len(text))
+
+		name, population, deleted, err := parseRecord(strings.TrimSuffix(text, "\n"))
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", db.path, line, err)
+		}
+		if deleted {
+			delete(db.index.capitals, name)
+		} else {
+			db.index.capitals[name] = population
+		}
+	}
+}
+
+func parseRecord(line string) (name string, population int, deleted bool, err error) {
+	quoted, err := strconv.QuotedPrefix(line)
+	if err != nil {
+		return "", 0, false, fmt.Errorf("expected a quoted name: %v", err)
+	}
+	name, _ = strconv.Unquote(quoted)
+	rest := strings.TrimSpace(line[len(quoted):])
+	if rest == "" {
+		return name, 0, true, nil
+	}
+	population, err = strconv.Atoi(rest)
+	if err != nil || population < 0 {
+		return "", 0, false, fmt.Errorf("invalid population %q", rest)
+	}
+	return name, population, false, nil
+}
+
+// Put sets the population of a city. The change is on disk when Put returns.
+func (db *FileDatabase) Put(name string, population int) error {
+	if population < 0 {
+		return fmt.Errorf("population of %q is negative", name)
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if err := db.append(strconv.Quote(name) + " " + strconv.Itoa(population) + "\n"); err != nil {
+		return err
+	}
+	db.index.capitals[name] = population
+	return nil
+}
+
+func (db *FileDatabase) Delete(name string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.index.capitals[name]; !ok {
+		return nil
+	}
+	if err := db.append(strconv.Quote(name) + "\n"); err != nil {
+		return err
+	}
+	delete(db.index.capitals, name)
+	return nil
+}
+
+func (db *FileDatabase) append(record string) error {
+	if _, err := db.file.WriteString(record); err != nil {
+		return err
+	}
+	return db.file.Sync()
+}
+
+// Compact replaces the file with one that only has the current cities. The new file is renamed over the old one,
+// so a crash leaves either of them in place, never a mix. It is opened for appending before the rename,
+// so when Compact fails, the database keeps writing to whichever file is at path.
+func (db *FileDatabase) Compact() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(db.path), filepath.Base(db.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once the rename has happened
+
+	w := bufio.NewWriter(tmp)
+	for _, city := range db.index.filter(func(string) bool { return true }) {
+		fmt.Fprintf(w, "%s %d\n", strconv.Quote(city.Name), city.Population)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// The open file follows the rename, and it is not too late to give up until then
+	file, err := os.OpenFile(tmp.Name(), os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), db.path); err != nil {
+		file.Close()
+		return err
+	}
+	db.file.Close()
+	db.file = file
+	return nil
+}
+
+func (db *FileDatabase) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.file.Close()
+}
+
+func (db *FileDatabase) GetPopulation(name string) (int, bool, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.index.GetPopulation(name)
+}
+
+// TotalPopulation looks every city up under one lock, so a concurrent Put cannot change the total half way.
+func (db *FileDatabase) TotalPopulation(cities []string) (int, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return sumPopulations(db.index.GetPopulation, cities)
+}
+
+func (db *FileDatabase) Cities() ([]City, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.index.Cities()
+}
+
+func (db *FileDatabase) SearchPrefix(prefix string) ([]City, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.index.SearchPrefix(prefix)
+}
+
+func (db *FileDatabase) TopN(n int) ([]City, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.index.TopN(n)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func openFile(t *testing.T, path string) *FileDatabase {
+	t.Helper()
+	db, err := OpenFileDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func cities(t *testing.T, db Database) []City {
+	t.Helper()
+	all, err := db.Cities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return all
+}
+
+func TestFilePutAndDeleteAreReplayed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capitals.db")
+	db := openFile(t, path)
+
+	odd := "Quote \" and\nnew line"
+	for _, c := range []City{{"Seoul", 1}, {"Lima", 2}, {odd, 3}, {"Seoul", 4}} {
+		if err := db.Put(c.Name, c.Population); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Delete("Lima"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete("Atlantis"); err != nil { // Unknown, so nothing is written
+		t.Fatal(err)
+	}
+	if err := db.Put("Nowhere", -1); err == nil {
+		t.Error("Put accepted a negative population")
+	}
+
+	want := []City{{odd, 3}, {"Seoul", 4}}
+	if got := cities(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("Cities() = %v, want %v", got, want)
+	}
+	wantFile := "\"Seoul\" 1\n\"Lima\" 2\n\"Quote \\\" and\\nnew line\" 3\n\"Seoul\" 4\n\"Lima\"\n"
+	if got := readFile(t, path); got != wantFile {
+		t.Errorf("the file holds\n%s\nwant\n%s", got, wantFile)
+	}
+
+	db.Close()
+	if got := cities(t, openFile(t, path)); !reflect.DeepEqual(got, want) {
+		t.Errorf("after reopening, Cities() = %v, want %v", got, want)
+	}
+}
+
+func TestFileCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capitals.db")
+	db := openFile(t, path)
+	for i, name := range []string{"Seoul", "Lima", "Seoul", "Oslo", "Lima"} {
+		if err := db.Put(name, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Delete("Oslo")
+
+	if err := db.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, path), "\"Lima\" 4\n\"Seoul\" 2\n"; got != want {
+		t.Errorf("after Compact, the file holds %q, want %q", got, want)
+	}
+	if leftovers, _ := filepath.Glob(path + ".*.tmp"); len(leftovers) != 0 {
+		t.Errorf("Compact left %q behind", leftovers)
+	}
+
+	// Later changes go to the new file, after the compacted lines
+	if err := db.Put("Oslo", 5); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, path), "\"Lima\" 4\n\"Seoul\" 2\n\"Oslo\" 5\n"; got != want {
+		t.Errorf("after a Put, the file holds %q, want %q", got, want)
+	}
+	db.Close()
+	if got, want := cities(t, openFile(t, path)), []City{{"Lima", 4}, {"Oslo", 5}, {"Seoul", 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after reopening, Cities() = %v, want %v", got, want)
+	}
+}
+
+func TestFileDropsATornLastLine(t *testing.T) {
+	tests := []struct {
+		name, file string
+		want       []City
+	}{
+		{"torn population", "\"Seoul\" 1\n\"Lima\" 2\n\"Oslo\" 12", []City{{"Lima", 2}, {"Seoul", 1}}},
+		{"torn name", "\"Seoul\" 1\n\"Li", []City{{"Seoul", 1}}},
+		{"torn deletion", "\"Seoul\" 1\n\"Seoul\"", []City{{"Seoul", 1}}},
+		{"only a torn line", "\"Seo", []City{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "capitals.db")
+			if err := os.WriteFile(path, []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			db := openFile(t, path)
+			if got := cities(t, db); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cities() = %v, want %v", got, tt.want)
+			}
+			complete := tt.file[:strings.LastIndex(tt.file, "\n")+1]
+			if got := readFile(t, path); got != complete {
+				t.Errorf("the file holds %q, want it truncated to %q", got, complete)
+			}
+
+			// The next record starts on a line of its own
+			if err := db.Put("Rome", 3); err != nil {
+				t.Fatal(err)
+			}
+			db.Close()
+			if _, ok, _ := openFile(t, path).GetPopulation("Rome"); !ok {
+				t.Error("the record written after the torn line was lost")
+			}
+		})
+	}
+}
+
+func TestFileReportsDamageInTheMiddle(t *testing.T) {
+	tests := []struct {
+		name, file, want string
+	}{
+		{"bad population", "\"Seoul\" 1\n\"Lima\" x\n\"Oslo\" 3\n", ":2: invalid population \"x\""},
+		{"negative", "\"Seoul\" -1\n", ":1: invalid population \"-1\""},
+		{"no quotes", "\"Seoul\" 1\nLima 2\n", ":2: expected a quoted name"},
+		// Ends with a new line, so it was written whole and is damaged, not torn
+		{"bad last line", "\"Seoul\" 1\n\"Lima\" 2x\n", ":2: invalid population \"2x\""},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "capitals.db")
+		if err := os.WriteFile(path, []byte(tt.file), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := OpenFileDatabase(path)
+		if err == nil || !strings.Contains(err.Error(), path+tt.want) {
+			t.Errorf("%s: OpenFileDatabase() = %v, want an error containing %q", tt.name, err, tt.want)
+		}
+		if got := readFile(t, path); got != tt.file {
+			t.Errorf("%s: the damaged file was changed to %q", tt.name, got)
+		}
+	}
+}
+
+func TestTotalPopulationOfEveryBackend(t *testing.T) {
+	capitals := map[string]int{"Seoul": 10, "Lima": 5, "Vatican City": 0}
+	file := openFile(t, filepath.Join(t.TempDir(), "capitals.db"))
+	for name, population := range capitals {
+		if err := file.Put(name, population); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backends := map[string]Database{
+		"memory": NewMemoryDatabase(capitals),
+		"dummy":  NewDummyDatabase(capitals),
+		"file":   file,
+	}
+
+	tests := []struct {
+		cities  []string
+		total   int
+		unknown string
+	}{
+		{nil, 0, ""},
+		{[]string{"Seoul", "Lima"}, 15, ""},
+		{[]string{"Seoul", "Seoul", "Vatican City"}, 20, ""}, // Listed twice, counted twice
+		{[]string{"Seoul", "Atlantis", "Lima", "El Dorado"}, 0, "Atlantis"},
+	}
+	for name, db := range backends {
+		for _, tt := range tests {
+			total, err := GetTotalPopulation(db, tt.cities)
+			if tt.unknown != "" {
+				if !errors.Is(err, ErrUnknownCity) || !strings.Contains(err.Error(), tt.unknown) {
+					t.Errorf("%s: GetTotalPopulation(%q) = %v, want %q to be unknown", name, tt.cities, err, tt.unknown)
+				}
+				continue
+			}
+			if err != nil || total != tt.total {
+				t.Errorf("%s: GetTotalPopulation(%q) = %d, %v, want %d", name, tt.cities, total, err, tt.total)
+			}
+		}
+	}
+}
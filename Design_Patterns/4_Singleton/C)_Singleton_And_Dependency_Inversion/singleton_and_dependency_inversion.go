@@ -1,12 +1,15 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"sync"
+
+	datasource "github.com/Germanchrystan/design_patterns_course/Design_Patterns/4_Singleton/D_Data_Source"
+	lazy "github.com/Germanchrystan/design_patterns_course/Design_Patterns/4_Singleton/E_Lazy"
 )
 
 /*
@@ -19,91 +22,141 @@ import (
 	First of all, we have to introduce some sort of abstraction,
 	which has something in commmon between the real db and the dummy db.
 */
-type Database interface {
-	GetPopulation(name string) int
-}
+/*
+	The Database interface, and the implementations behind it, are in database.go.
+	The singleton database is simply a MemoryDatabase that is created only once.
+*/
 
-type singletonDatabase struct {
-	capitals map[string]int
-}
+/*
+	Instead of a sync.Once and an instance variable, the database is resolved through a Lazy.
+	It is still created only once, on first use, but callers only see the Database interface,
+	and a test can put a different Database in its context, or override it with the lazytest build tag.
+*/
+var database = lazy.New(func() (Database, error) {
+	caps, err := datasource.Load(datasource.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("loading capitals: %w", err)
+	}
+	return NewMemoryDatabase(caps), nil
+})
 
-func (db *singletonDatabase) GetPopulation(name string) int {
-	return db.capitals[name]
+// GetDatabase returns the Database scoped to ctx, or the singleton database when there is none.
+func GetDatabase(ctx context.Context) (Database, error) {
+	return database.From(ctx)
 }
 
-var once sync.Once
-var instance *singletonDatabase
+/*
+	DummyDatabase is a fake for tests. It answers from the data it was given,
+	remembers which cities were looked up, and fails every call with Err when that is set,
+	so a test can check both what the code asked for and how it handles a broken database.
+	The code under test may look cities up from several goroutines, so the lookups are kept under a mutex.
+	Err is only read by the calls, and has to be set before the database is handed out.
+*/
+type DummyDatabase struct {
+	*MemoryDatabase
+	Err error
 
-func GetSingletonDatabase() *singletonDatabase {
-	once.Do(func() {
-		caps, err := readData(".\\capitals.txt")
-		db := singletonDatabase{caps}
-		if err == nil {
-			db.capitals = caps
-		}
-		instance = &db
-	})
-	return instance
+	mu      sync.Mutex
+	lookups []string
 }
 
-func readData(path string) (map[string]int, error) {
-	ex, err := os.Executable()
-	if err != nil {
-		panic(err)
-	}
-	exPath := filepath.Dir(ex)
+func NewDummyDatabase(data map[string]int) *DummyDatabase {
+	return &DummyDatabase{MemoryDatabase: NewMemoryDatabase(data)}
+}
 
-	file, err := os.Open(exPath + path)
-	if err != nil {
-		return nil, err
+func (d *DummyDatabase) GetPopulation(name string) (int, bool, error) {
+	d.mu.Lock()
+	d.lookups = append(d.lookups, name)
+	d.mu.Unlock()
+	if d.Err != nil {
+		return 0, false, d.Err
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
-
-	result := map[string]int{}
+	return d.MemoryDatabase.GetPopulation(name)
+}
 
-	for scanner.Scan() {
-		k := scanner.Text()
-		scanner.Scan()
-		v, _ := strconv.Atoi(scanner.Text())
-		result[k] = v
-	}
-	return result, nil
+// Lookups returns the cities looked up so far, in the order of the calls.
+func (d *DummyDatabase) Lookups() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string{}, d.lookups...)
 }
 
-func GetTotalPopulation(db Database, cities []string) int {
-	result := 0
-	for _, city := range cities {
-		result += db.GetPopulation(city)
-		//         Using interface instead of singleton struct method
+func (d *DummyDatabase) Cities() ([]City, error) {
+	if d.Err != nil {
+		return nil, d.Err
 	}
-	return result
+	return d.MemoryDatabase.Cities()
 }
 
-type DummyDatabase struct {
-	dummyData map[string]int
+func (d *DummyDatabase) SearchPrefix(prefix string) ([]City, error) {
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	return d.MemoryDatabase.SearchPrefix(prefix)
 }
 
-func (d *DummyDatabase) GetPopulation(name string) int {
-	if len(d.dummyData) == 0 {
-		d.dummyData = map[string]int{
-			"alpha": 1,
-			"beta":  2,
-			"gamma": 3,
-		}
+func (d *DummyDatabase) TopN(n int) ([]City, error) {
+	if d.Err != nil {
+		return nil, d.Err
 	}
-	return d.dummyData[name]
+	return d.MemoryDatabase.TopN(n)
 }
 
 func main() {
+	dummy := NewDummyDatabase(map[string]int{
+		"alpha": 1,
+		"beta":  2,
+		"gamma": 3,
+	})
 	cities := []string{"alpha", "gamma"}
-	tp := GetTotalPopulation(&DummyDatabase{}, cities)
+	tp, err := GetTotalPopulation(dummy, cities)
+
+	ok := err == nil && tp == 4
+	fmt.Println(ok, dummy.Lookups())
+
+	// Unknown cities are no longer counted as empty ones
+	_, err = GetTotalPopulation(dummy, []string{"delta"})
+	fmt.Println(errors.Is(err, ErrUnknownCity))
 
-	ok := tp == 4
-	fmt.Println(ok)
+	// Code that resolves the database itself can be given the dummy through its context
+	ctx := database.WithValue(context.Background(), dummy)
+	db, err := GetDatabase(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	tp, err = GetTotalPopulation(db, cities)
+	fmt.Println(err == nil && tp == 4)
 
-	// if we want to use the actual singleton db to get total population we could write:
-	// tp := GetTotalPopulation(GetSingletonDatabase(), citites)
+	// Without a scoped value, we get the actual singleton db
+	db, err = GetDatabase(context.Background())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	top, _ := db.TopN(3)
+	fmt.Println(top)
+
+	// The same data can be kept in an embedded file store instead
+	dir, err := os.MkdirTemp("", "capitals")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	store, err := OpenFileDatabase(filepath.Join(dir, "capitals.db"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer store.Close()
+	all, _ := db.Cities()
+	for _, city := range all {
+		if err := store.Put(city.Name, city.Population); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	found, _ := store.SearchPrefix("M")
+	fmt.Println(found)
 }
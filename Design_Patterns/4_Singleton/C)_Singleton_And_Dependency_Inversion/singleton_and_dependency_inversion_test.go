@@ -0,0 +1,32 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestDummyDatabaseLookupsFromManyGoroutines(t *testing.T) {
+	dummy := NewDummyDatabase(map[string]int{"alpha": 1, "beta": 2})
+	cities := []string{"alpha", "beta", "gamma"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			GetTotalPopulation(dummy, cities[:2])
+			dummy.GetPopulation(cities[2])
+		}()
+	}
+	wg.Wait()
+
+	lookups := dummy.Lookups()
+	if len(lookups) != 150 {
+		t.Fatalf("%d lookups recorded, want 150", len(lookups))
+	}
+	sort.Strings(lookups)
+	if lookups[0] != "alpha" || lookups[50] != "beta" || lookups[100] != "gamma" {
+		t.Errorf("unexpected lookups: %v", lookups)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+	SQLDatabase keeps the cities in a table of a database/sql database. It does not import a driver,
+	so the program chooses one and opens the *sql.DB itself, for example with SQLite:
+		import _ "github.com/mattn/go-sqlite3"
+		conn, err := sql.Open("sqlite3", "capitals.db")
+		db, err := NewSQLDatabase(conn)
+
+	The SQL is written for SQLite. sql_database_test.go runs every query against SQLite, with that driver.
+*/
+
+const createCapitalsTable = `CREATE TABLE IF NOT EXISTS capitals (
+	name       TEXT    NOT NULL PRIMARY KEY,
+	population INTEGER NOT NULL CHECK (population >= 0)
+)`
+
+type SQLDatabase struct {
+	db *sql.DB
+}
+
+// NewSQLDatabase creates the capitals table when it does not exist yet.
+func NewSQLDatabase(db *sql.DB) (*SQLDatabase, error) {
+	if _, err := db.Exec(createCapitalsTable); err != nil {
+		return nil, err
+	}
+	return &SQLDatabase{db}, nil
+}
+
+// Import adds or replaces the cities in a single transaction, so either all of them are stored or none.
+func (s *SQLDatabase) Import(capitals map[string]int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Does nothing once the transaction has been committed
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO capitals (name, population) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for name, population := range capitals {
+		if _, err := stmt.Exec(name, population); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLDatabase) GetPopulation(name string) (int, bool, error) {
+	var population int
+	err := s.db.QueryRow(`SELECT population FROM capitals WHERE name = ?`, name).Scan(&population)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return population, true, nil
+}
+
+func (s *SQLDatabase) Cities() ([]City, error) {
+	return s.query(`SELECT name, population FROM capitals ORDER BY name`)
+}
+
+// The prefix is compared with substr instead of LIKE, which would treat % and _ as wildcards
+// and ignore case in SQLite.
+func (s *SQLDatabase) SearchPrefix(prefix string) ([]City, error) {
+	return s.query(`SELECT name, population FROM capitals
+		WHERE substr(name, 1, length(?)) = ? ORDER BY name`, prefix, prefix)
+}
+
+func (s *SQLDatabase) TopN(n int) ([]City, error) {
+	if n < 0 {
+		n = 0
+	}
+	return s.query(`SELECT name, population FROM capitals ORDER BY population DESC, name LIMIT ?`, n)
+}
+
+// TotalPopulation joins the listed cities, with their position in the list, to the table.
+// A city listed twice is a row of its own, so it is counted twice, and the smallest position without a match
+// is the first unknown city. The positions are written into the query, only the names are parameters.
+func (s *SQLDatabase) TotalPopulation(cities []string) (int, error) {
+	if len(cities) == 0 {
+		return 0, nil
+	}
+	var values strings.Builder
+	args := make([]interface{}, len(cities))
+	for i, city := range cities {
+		if i > 0 {
+			values.WriteString(", ")
+		}
+		values.WriteString("(" + strconv.Itoa(i) + ", ?)")
+		args[i] = city
+	}
+	var total int
+	var unknown sql.NullInt64
+	err := s.db.QueryRow(`WITH wanted (position, name) AS (VALUES `+values.String()+`)
+		SELECT COALESCE(SUM(capitals.population), 0), MIN(CASE WHEN capitals.name IS NULL THEN wanted.position END)
+		FROM wanted LEFT JOIN capitals ON capitals.name = wanted.name`, args...).Scan(&total, &unknown)
+	if err != nil {
+		return 0, err
+	}
+	if unknown.Valid {
+		return 0, fmt.Errorf("%w %q", ErrUnknownCity, cities[unknown.Int64])
+	}
+	return total, nil
+}
+
+func (s *SQLDatabase) query(query string, args ...interface{}) ([]City, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cities := []City{}
+	for rows.Next() {
+		var c City
+		if err := rows.Scan(&c.Name, &c.Population); err != nil {
+			return nil, err
+		}
+		cities = append(cities, c)
+	}
+	return cities, rows.Err()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openSQLite gives every test a database file of its own. The driver needs cgo, so without it the tests are skipped.
+func openSQLite(t *testing.T, capitals map[string]int) *SQLDatabase {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "capitals.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := conn.Ping(); err != nil {
+		t.Skip("SQLite is not available:", err)
+	}
+	db, err := NewSQLDatabase(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Import(capitals); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSQLGetPopulation(t *testing.T) {
+	db := openSQLite(t, map[string]int{"Seoul": 17500000, "Vatican City": 0})
+
+	tests := []struct {
+		name       string
+		population int
+		ok         bool
+	}{
+		{"Seoul", 17500000, true},
+		{"Vatican City", 0, true}, // Known, but nobody lives there
+		{"Atlantis", 0, false},
+		{"seoul", 0, false},
+	}
+	for _, tt := range tests {
+		population, ok, err := db.GetPopulation(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if population != tt.population || ok != tt.ok {
+			t.Errorf("GetPopulation(%q) = %d, %v, want %d, %v", tt.name, population, ok, tt.population, tt.ok)
+		}
+	}
+}
+
+func TestSQLSearchPrefix(t *testing.T) {
+	db := openSQLite(t, map[string]int{
+		"Mexico City": 9,
+		"Madrid":      3,
+		"madrid":      1,
+		"100% City":   2,
+		"1000 Hills":  4,
+		"A_B":         5,
+		"AxB":         6,
+	})
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"M", []string{"Madrid", "Mexico City"}},
+		{"m", []string{"madrid"}},
+		{"100%", []string{"100% City"}}, // LIKE would match "1000 Hills" too
+		{"%", nil},
+		{"A_", []string{"A_B"}}, // LIKE would match "AxB" too
+		{"_", nil},
+		{"", []string{"100% City", "1000 Hills", "A_B", "AxB", "Madrid", "Mexico City", "madrid"}},
+	}
+	for _, tt := range tests {
+		cities, err := db.SearchPrefix(tt.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(cities); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SearchPrefix(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestSQLTopNWithTies(t *testing.T) {
+	db := openSQLite(t, map[string]int{"Delta": 5, "Alpha": 9, "Charlie": 5, "Bravo": 5, "Echo": 1})
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{1, []string{"Alpha"}},
+		{3, []string{"Alpha", "Bravo", "Charlie"}}, // The tie is broken by name
+		{10, []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"}},
+		{0, nil},
+		{-1, nil},
+	}
+	for _, tt := range tests {
+		cities, err := db.TopN(tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(cities); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TopN(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+
+	// The memory database has to agree, since both implement the same interface
+	memory, _ := NewMemoryDatabase(map[string]int{"Delta": 5, "Alpha": 9, "Charlie": 5, "Bravo": 5, "Echo": 1}).TopN(3)
+	if got := names(memory); !reflect.DeepEqual(got, []string{"Alpha", "Bravo", "Charlie"}) {
+		t.Errorf("MemoryDatabase.TopN(3) = %q", got)
+	}
+}
+
+func TestSQLImportRollsBack(t *testing.T) {
+	db := openSQLite(t, map[string]int{"Seoul": 17500000})
+
+	// The negative population breaks the CHECK constraint, whichever order the map is imported in
+	err := db.Import(map[string]int{"Seoul": 1, "Tokyo": 37000000, "Nowhere": -1, "Lima": 10000000})
+	if err == nil {
+		t.Fatal("Import accepted a negative population")
+	}
+	cities, err := db.Cities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []City{{"Seoul", 17500000}}; !reflect.DeepEqual(cities, want) {
+		t.Errorf("after a failed import, Cities() = %v, want %v", cities, want)
+	}
+
+	// The failed transaction left nothing locked behind
+	if err := db.Import(map[string]int{"Lima": 10000000}); err != nil {
+		t.Fatal(err)
+	}
+	if population, ok, err := db.GetPopulation("Lima"); err != nil || !ok || population != 10000000 {
+		t.Errorf("GetPopulation(Lima) = %d, %v, %v after a good import", population, ok, err)
+	}
+}
+
+func TestSQLTotalPopulation(t *testing.T) {
+	db := openSQLite(t, map[string]int{"Seoul": 10, "Lima": 5, "Vatican City": 0, "100%": 1})
+	var _ PopulationCounter = db // GetTotalPopulation leaves the sum to SQLite
+
+	tests := []struct {
+		cities  []string
+		total   int
+		unknown string
+	}{
+		{nil, 0, ""},
+		{[]string{"Seoul", "Lima"}, 15, ""},
+		{[]string{"Seoul", "Seoul", "Vatican City"}, 20, ""},
+		{[]string{"Vatican City"}, 0, ""},
+		{[]string{"Seoul", "El Dorado", "Atlantis"}, 0, "El Dorado"}, // The first unknown in the list, not by name
+		{[]string{"100_"}, 0, "100_"},
+		{[]string{"seoul"}, 0, "seoul"},
+	}
+	for _, tt := range tests {
+		total, err := GetTotalPopulation(db, tt.cities)
+		if tt.unknown != "" {
+			if !errors.Is(err, ErrUnknownCity) || !strings.Contains(err.Error(), `"`+tt.unknown+`"`) {
+				t.Errorf("GetTotalPopulation(%q) = %v, want %q to be unknown", tt.cities, err, tt.unknown)
+			}
+			continue
+		}
+		if err != nil || total != tt.total {
+			t.Errorf("GetTotalPopulation(%q) = %d, %v, want %d", tt.cities, total, err, tt.total)
+		}
+	}
+}
+
+func names(cities []City) []string {
+	var result []string
+	for _, c := range cities {
+		result = append(result, c.Name)
+	}
+	return result
+}
@@ -0,0 +1,295 @@
+package datasource
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/*
+The singleton examples used to read ".\capitals.txt" next to the executable. That path only works on Windows,
+and with "go run" the executable lives in a temporary directory anyway. Read errors were thrown away, so a missing
+file gave an empty database, and a city without a population line was silently read as 0.
+
+This package loads the capitals from a source given in Options, or from the environment when the options leave it open:
+  - CAPITALS_FILE is the path of the file.
+  - CAPITALS_FORMAT is "lines", "csv" or "json". Without it, the format comes from the extension of the file.
+
+When neither says where the file is, capitals.txt is looked up in the working directory, then next to the executable,
+and when it is not there either, the copy of capitals.txt embedded in this package is used. That copy is the only one
+in the repository, so the examples can run from any directory without a file of their own.
+
+Three formats are understood:
+  - lines: the name of a city on one line, and its population on the next one (capitals.txt).
+  - csv: one "name,population" record per line, with an optional "name,population" header.
+  - json: an object mapping every name to its population.
+*/
+
+const (
+	EnvFile   = "CAPITALS_FILE"
+	EnvFormat = "CAPITALS_FORMAT"
+
+	// DefaultFile is looked up in the working directory first, and then next to the executable.
+	DefaultFile = "capitals.txt"
+)
+
+//go:embed capitals.txt
+var embedded []byte
+
+// Errors in the embedded copy point at this name.
+const embeddedSource = "embedded " + DefaultFile
+
+// DefaultData returns the embedded capitals.txt, in the lines format.
+func DefaultData() []byte {
+	return append([]byte(nil), embedded...)
+}
+
+type Format string
+
+const (
+	// Auto picks the format from the extension of the file: .csv, .json, and the two-line format for anything else.
+	Auto  Format = ""
+	Lines Format = "lines"
+	CSV   Format = "csv"
+	JSON  Format = "json"
+)
+
+type Options struct {
+	Path   string
+	Format Format
+}
+
+var ErrUnknownFormat = errors.New("unknown capitals format")
+
+// FormatError points at the line of the source the problem was found on, starting at 1.
+type FormatError struct {
+	Source string
+	Line   int
+	Msg    string
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Source, e.Line, e.Msg)
+}
+
+// Resolve fills in whatever the options leave open, from the environment and then from the defaults.
+// The path stays empty when the embedded copy is to be used.
+func (o Options) Resolve() Options {
+	if o.Path == "" {
+		o.Path = os.Getenv(EnvFile)
+	}
+	if o.Path == "" {
+		o.Path = defaultPath()
+	}
+	if o.Format == Auto {
+		o.Format = Format(strings.ToLower(os.Getenv(EnvFormat)))
+	}
+	if o.Format == Auto {
+		switch strings.ToLower(filepath.Ext(o.Path)) {
+		case ".csv":
+			o.Format = CSV
+		case ".json":
+			o.Format = JSON
+		default:
+			o.Format = Lines
+		}
+	}
+	return o
+}
+
+func defaultPath() string {
+	if _, err := os.Stat(DefaultFile); err == nil {
+		return DefaultFile
+	}
+	if ex, err := os.Executable(); err == nil {
+		path := filepath.Join(filepath.Dir(ex), DefaultFile)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load reads the capitals from the source described by the options.
+func Load(opts Options) (map[string]int, error) {
+	opts = opts.Resolve()
+	if opts.Path == "" {
+		return Read(bytes.NewReader(embedded), embeddedSource, Lines)
+	}
+	file, err := os.Open(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file, opts.Path, opts.Format)
+}
+
+// Read parses the capitals from r. The name of the source is only used in error messages.
+func Read(r io.Reader, source string, format Format) (map[string]int, error) {
+	switch format {
+	case Lines, Auto:
+		return readLines(r, source)
+	case CSV:
+		return readCSV(r, source)
+	case JSON:
+		return readJSON(r, source)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
+
+// result collects the capitals and rejects populations and names that make no sense.
+type result struct {
+	source   string
+	capitals map[string]int
+	lines    map[string]int // Where each name was first seen
+}
+
+func newResult(source string) *result {
+	return &result{source: source, capitals: map[string]int{}, lines: map[string]int{}}
+}
+
+func (r *result) errorf(line int, format string, args ...interface{}) error {
+	return &FormatError{Source: r.source, Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (r *result) add(name string, nameLine int, population string, populationLine int) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return r.errorf(nameLine, "empty city name")
+	}
+	if first, ok := r.lines[name]; ok {
+		return r.errorf(nameLine, "%q is already listed on line %d", name, first)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(population))
+	if err != nil {
+		return r.errorf(populationLine, "population of %q is not a number: %q", name, population)
+	}
+	if n < 0 {
+		return r.errorf(populationLine, "population of %q is negative", name)
+	}
+	r.capitals[name] = n
+	r.lines[name] = nameLine
+	return nil
+}
+
+func readLines(rd io.Reader, source string) (map[string]int, error) {
+	res := newResult(source)
+	scanner := bufio.NewScanner(rd)
+	line := 0
+	for scanner.Scan() {
+		line++
+		name := scanner.Text()
+		if strings.TrimSpace(name) == "" {
+			continue // Blank lines between the pairs, or at the end of the file
+		}
+		nameLine := line
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, res.errorf(nameLine, "%q has no population line", strings.TrimSpace(name))
+		}
+		line++
+		if err := res.add(name, nameLine, scanner.Text(), line); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res.capitals, nil
+}
+
+func readCSV(rd io.Reader, source string) (map[string]int, error) {
+	res := newResult(source)
+	cr := csv.NewReader(rd)
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return res.capitals, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, res.errorf(parseErr.Line, "%v", parseErr.Err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if first && strings.EqualFold(record[0], "name") && strings.EqualFold(record[1], "population") {
+			continue
+		}
+		if err := res.add(record[0], line, record[1], line); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readJSON(rd io.Reader, source string) (map[string]int, error) {
+	// The whole input is kept, to turn the offsets of the decoder into line numbers.
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	lineAt := func(offset int64) int {
+		return 1 + strings.Count(string(data[:offset]), "\n")
+	}
+
+	res := newResult(source)
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	fail := func(err error) (map[string]int, error) {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, res.errorf(lineAt(syntaxErr.Offset), "%v", err)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, res.errorf(lineAt(int64(len(data))), "unexpected end of input")
+		}
+		return nil, res.errorf(lineAt(dec.InputOffset()), "%v", err)
+	}
+
+	if tok, err := dec.Token(); err != nil {
+		return fail(err)
+	} else if tok != json.Delim('{') {
+		return nil, res.errorf(lineAt(dec.InputOffset()), "expected an object of populations, got %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fail(err)
+		}
+		name := tok.(string) // Keys of an object are always strings
+		nameLine := lineAt(dec.InputOffset())
+		tok, err = dec.Token()
+		if err != nil {
+			return fail(err)
+		}
+		valueLine := lineAt(dec.InputOffset())
+		n, ok := tok.(json.Number)
+		if !ok {
+			return nil, res.errorf(valueLine, "population of %q is not a number: %v", name, tok)
+		}
+		if err := res.add(name, nameLine, n.String(), valueLine); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fail(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, res.errorf(lineAt(dec.InputOffset()), "unexpected data after the object")
+	}
+	return res.capitals, nil
+}
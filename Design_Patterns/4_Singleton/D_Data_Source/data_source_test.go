@@ -0,0 +1,165 @@
+package datasource
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	want := map[string]int{"Seoul": 17500000, "Mexico City": 17400000, "Vatican City": 0}
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+	}{
+		{"lines", Lines, "Seoul\n17500000\n\nMexico City\n 17400000 \nVatican City\n0\n\n"},
+		{"lines without a final newline", Auto, "Seoul\n17500000\nMexico City\n17400000\nVatican City\n0"},
+		{"csv with a header", CSV, "Name,Population\nSeoul,17500000\n\"Mexico City\", 17400000\nVatican City,0\n"},
+		{"csv without a header", CSV, "Seoul,17500000\nMexico City,17400000\nVatican City,0"},
+		{"json", JSON, `{
+			"Seoul": 17500000,
+			"Mexico City": 17400000,
+			"Vatican City": 0
+		}`},
+	}
+	for _, tt := range tests {
+		got, err := Read(strings.NewReader(tt.data), "test", tt.format)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestReadReportsTheLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+		line   int
+		msg    string
+	}{
+		{"lines: no population", Lines, "Seoul\n1\n\nLima", 4, `"Lima" has no population line`},
+		{"lines: not a number", Lines, "Seoul\n1\nLima\nmany\n", 4, `population of "Lima" is not a number: "many"`},
+		{"lines: negative", Lines, "Seoul\n-1\n", 2, `population of "Seoul" is negative`},
+		{"lines: listed twice", Lines, "Seoul\n1\n\nLima\n2\nSeoul\n3\n", 6, `"Seoul" is already listed on line 1`},
+		{"csv: too many fields", CSV, "name,population\nSeoul,1\nLima,2,3\n", 3, "wrong number of fields"},
+		{"csv: not a number", CSV, "Seoul,1\nLima,x\n", 2, `population of "Lima" is not a number: "x"`},
+		{"csv: empty name", CSV, "Seoul,1\n ,2\n", 2, "empty city name"},
+		{"csv: listed twice", CSV, "Seoul,1\nLima,2\nLima,3\n", 3, `"Lima" is already listed on line 2`},
+		{"csv: bad quote", CSV, "Seoul,1\n\"Lima,2\n", 2, "extraneous or missing \" in quoted-field"},
+		{"json: not a number", JSON, "{\n  \"Seoul\": 1,\n  \"Lima\": \"many\"\n}", 3, `population of "Lima" is not a number: many`},
+		{"json: negative", JSON, "{\n  \"Seoul\": -5\n}", 2, `population of "Seoul" is negative`},
+		{"json: fraction", JSON, "{\"Seoul\": 1,\n\"Lima\": 2.5}", 2, `population of "Lima" is not a number: "2.5"`},
+		{"json: listed twice", JSON, "{\n\"Seoul\": 1,\n\"Seoul\": 2\n}", 3, `"Seoul" is already listed on line 2`},
+		{"json: syntax", JSON, "{\n  \"Seoul\": 1\n  \"Lima\": 2\n}", 3, "invalid character"},
+		{"json: not an object", JSON, "\n[1, 2]", 2, "expected an object of populations"},
+		{"json: cut short", JSON, "{\n\"Seoul\": 1,\n", 3, "unexpected end of"},
+		{"json: trailing data", JSON, "{\"Seoul\": 1}\n{}", 2, "unexpected data after the object"},
+	}
+	for _, tt := range tests {
+		_, err := Read(strings.NewReader(tt.data), "capitals", tt.format)
+		var formatErr *FormatError
+		if !errors.As(err, &formatErr) {
+			t.Errorf("%s: got %v, want a FormatError", tt.name, err)
+			continue
+		}
+		if formatErr.Source != "capitals" || formatErr.Line != tt.line || !strings.Contains(formatErr.Msg, tt.msg) {
+			t.Errorf("%s: got %q, want line %d and a message containing %q", tt.name, err, tt.line, tt.msg)
+		}
+	}
+
+	if _, err := Read(strings.NewReader(""), "capitals", "xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Read(xml) = %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	csvPath := write("capitals.csv", "Seoul,1\n")
+	jsonPath := write("capitals.JSON", `{"Seoul": 2}`)
+	linesPath := write("capitals.dat", "Seoul\n3\n")
+	t.Setenv(EnvFile, "")
+	t.Setenv(EnvFormat, "")
+
+	tests := []struct {
+		name string
+		opts Options
+		env  map[string]string
+		want int
+	}{
+		{"csv by extension", Options{Path: csvPath}, nil, 1},
+		{"json by extension", Options{Path: jsonPath}, nil, 2},
+		{"lines for anything else", Options{Path: linesPath}, nil, 3},
+		{"explicit format", Options{Path: linesPath, Format: Lines}, nil, 3},
+		{"path from the environment", Options{}, map[string]string{EnvFile: csvPath}, 1},
+		{"format from the environment", Options{Path: linesPath}, map[string]string{EnvFormat: "LINES"}, 3},
+		{"options before the environment", Options{Path: jsonPath}, map[string]string{EnvFile: csvPath}, 2},
+	}
+	for _, tt := range tests {
+		for k, v := range tt.env {
+			os.Setenv(k, v)
+		}
+		got, err := Load(tt.opts)
+		for k := range tt.env {
+			os.Setenv(k, "")
+		}
+		if err != nil || len(got) != 1 || got["Seoul"] != tt.want {
+			t.Errorf("%s: Load() = %v, %v, want Seoul at %d", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := Load(Options{Path: filepath.Join(dir, "missing.txt")}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load(missing) = %v, want os.ErrNotExist", err)
+	}
+	if _, err := Load(Options{Path: linesPath, Format: CSV}); err == nil {
+		t.Error("the lines file was read as CSV without an error")
+	}
+}
+
+// Without a path anywhere, and without capitals.txt in the working directory, the embedded copy is used.
+func TestLoadFallsBackToTheEmbeddedCopy(t *testing.T) {
+	t.Setenv(EnvFile, "")
+	t.Setenv(EnvFormat, "")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	got, err := Load(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Read(bytes.NewReader(DefaultData()), "embedded", Lines)
+	if err != nil || len(want) == 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %v, want the %d embedded capitals (%v)", got, len(want), err)
+	}
+
+	// DefaultData hands out a copy, so changing it does not change what Load reads next
+	data := DefaultData()
+	for i := range data {
+		data[i] = 'x'
+	}
+	if again, err := Load(Options{}); err != nil || !reflect.DeepEqual(again, want) {
+		t.Errorf("after changing DefaultData, Load() = %v, %v", again, err)
+	}
+}
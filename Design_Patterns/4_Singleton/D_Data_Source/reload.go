@@ -0,0 +1,202 @@
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Load reads the capitals once, but the population data is updated while services are running.
+A Reloader keeps the current data in an immutable Snapshot. A reload builds a complete new snapshot on the side and
+then swaps it in atomically, so a reader sees either the old data or the new data, never a half loaded map.
+A reader that needs several answers to agree with each other should take one Snapshot and ask it all of them.
+
+When a reload fails, for example because the file is being written at that moment, the old snapshot stays in place.
+*/
+
+type Snapshot struct {
+	capitals map[string]int
+	// Version starts at 1 and goes up by one with every successful reload.
+	Version  uint64
+	LoadedAt time.Time
+}
+
+func (s *Snapshot) GetPopulation(name string) int {
+	return s.capitals[name]
+}
+
+// Capitals returns a copy, so the snapshot cannot be changed through it.
+func (s *Snapshot) Capitals() map[string]int {
+	capitals := make(map[string]int, len(s.capitals))
+	for name, population := range s.capitals {
+		capitals[name] = population
+	}
+	return capitals
+}
+
+type Reloader struct {
+	opts    Options
+	current atomic.Value // *Snapshot
+
+	// reloadMu makes reloads happen one at a time, so the versions and the notifications stay in order.
+	reloadMu sync.Mutex
+	sum      [sha256.Size]byte // Of the file behind the current snapshot
+
+	subMu       sync.Mutex
+	subscribers map[int]func(*Snapshot)
+	nextID      int
+}
+
+// NewReloader loads the capitals once. Call Reload or Watch to pick up later changes.
+func NewReloader(opts Options) (*Reloader, error) {
+	r := &Reloader{opts: opts.Resolve(), subscribers: map[int]func(*Snapshot){}}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Snapshot returns the data as it is right now. It never changes, even when the file is reloaded later.
+func (r *Reloader) Snapshot() *Snapshot {
+	return r.current.Load().(*Snapshot)
+}
+
+func (r *Reloader) GetPopulation(name string) int {
+	return r.Snapshot().GetPopulation(name)
+}
+
+// Reload reads the file again and notifies the subscribers once the new snapshot is in place.
+func (r *Reloader) Reload() error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+	return r.reload()
+}
+
+func (r *Reloader) reload() error {
+	data, err := r.readFile()
+	if err != nil {
+		return err
+	}
+	return r.swap(data)
+}
+
+// swap parses data and makes it the current snapshot. data is nil for the embedded copy.
+func (r *Reloader) swap(data []byte) error {
+	var capitals map[string]int
+	var err error
+	if data == nil {
+		capitals, err = Load(r.opts)
+	} else {
+		capitals, err = Read(bytes.NewReader(data), r.opts.Path, r.opts.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	var version uint64 = 1
+	if previous, ok := r.current.Load().(*Snapshot); ok {
+		version = previous.Version + 1
+	}
+	snapshot := &Snapshot{capitals: capitals, Version: version, LoadedAt: time.Now()}
+	r.current.Store(snapshot)
+	if data != nil {
+		r.sum = sha256.Sum256(data)
+	}
+	r.notify(snapshot)
+	return nil
+}
+
+// readFile returns nil for the embedded copy, which has no file.
+func (r *Reloader) readFile() ([]byte, error) {
+	if r.opts.Path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(r.opts.Path)
+	if data == nil && err == nil {
+		data = []byte{} // An empty file is still a file
+	}
+	return data, err
+}
+
+/*
+Subscribers are called one after the other, after every successful reload, from the goroutine doing the reload.
+They must not call Reload themselves, and should hand anything slow over to a goroutine of their own.
+*/
+
+// Subscribe registers fn to be called with every new snapshot. Calling the returned function unsubscribes it.
+func (r *Reloader) Subscribe(fn func(*Snapshot)) (unsubscribe func()) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	id := r.nextID
+	r.nextID++
+	r.subscribers[id] = fn
+	return func() {
+		r.subMu.Lock()
+		defer r.subMu.Unlock()
+		delete(r.subscribers, id)
+	}
+}
+
+func (r *Reloader) notify(snapshot *Snapshot) {
+	r.subMu.Lock()
+	ids := make([]int, 0, len(r.subscribers))
+	for id := range r.subscribers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids) // In the order they subscribed
+	subscribers := make([]func(*Snapshot), len(ids))
+	for i, id := range ids {
+		subscribers[i] = r.subscribers[id]
+	}
+	r.subMu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(snapshot)
+	}
+}
+
+/*
+Watch tells whether the file changed by its content, not by its modification time and size. The modification time has
+a coarse resolution on some file systems, so a rewrite of the same size within it would go unnoticed. Reading and
+hashing the file on every check is cheap at the size of a list of capitals.
+A file that is touched, or written again with the same content, is not reloaded and does not bump the version.
+*/
+
+// Watch checks the file every interval and reloads it when its content has changed, until ctx is done.
+// Reload errors are passed to onError, which may be nil.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.reloadIfChanged(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (r *Reloader) reloadIfChanged() error {
+	if r.opts.Path == "" {
+		return nil // The embedded copy never changes
+	}
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+	data, err := r.readFile()
+	if err != nil {
+		return err
+	}
+	if sha256.Sum256(data) == r.sum {
+		return nil
+	}
+	return r.swap(data)
+}
@@ -0,0 +1,166 @@
+package datasource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCapitals(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadSwapsTheSnapshotAndNotifies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capitals.txt")
+	writeCapitals(t, path, "Seoul\n1\n")
+	r, err := NewReloader(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := r.Snapshot()
+	if old.Version != 1 || r.GetPopulation("Seoul") != 1 {
+		t.Fatalf("first snapshot is version %d with Seoul at %d", old.Version, r.GetPopulation("Seoul"))
+	}
+
+	var got []string
+	unsubscribeFirst := r.Subscribe(func(s *Snapshot) { got = append(got, "first") })
+	r.Subscribe(func(s *Snapshot) {
+		got = append(got, "second")
+		if s != r.Snapshot() {
+			t.Error("a subscriber was called before the new snapshot was in place")
+		}
+	})
+
+	writeCapitals(t, path, "Seoul\n2\nLima\n3\n")
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if s := r.Snapshot(); s.Version != 2 || s.GetPopulation("Seoul") != 2 || s.GetPopulation("Lima") != 3 {
+		t.Errorf("after a reload: version %d, %v", s.Version, s.Capitals())
+	}
+	if old.Version != 1 || old.GetPopulation("Seoul") != 1 || old.GetPopulation("Lima") != 0 {
+		t.Errorf("the old snapshot changed: version %d, %v", old.Version, old.Capitals())
+	}
+	if len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Errorf("notified %q, want both subscribers in the order they subscribed", got)
+	}
+
+	got = nil
+	unsubscribeFirst()
+	if err := r.Reload(); err != nil || r.Snapshot().Version != 3 {
+		t.Fatalf("Reload() = %v, version %d", err, r.Snapshot().Version)
+	}
+	if len(got) != 1 || got[0] != "second" {
+		t.Errorf("after unsubscribing the first, notified %q", got)
+	}
+
+	// A failed reload keeps the snapshot and tells nobody
+	got = nil
+	writeCapitals(t, path, "Seoul\nmany\n")
+	if err := r.Reload(); err == nil {
+		t.Error("Reload() of a broken file succeeded")
+	}
+	if s := r.Snapshot(); s.Version != 3 || s.GetPopulation("Seoul") != 2 || len(got) != 0 {
+		t.Errorf("after a failed reload: version %d, %v, notified %q", s.Version, s.Capitals(), got)
+	}
+
+	// Capitals hands out a copy
+	r.Snapshot().Capitals()["Seoul"] = 100
+	if r.GetPopulation("Seoul") != 2 {
+		t.Error("changing the map from Capitals changed the snapshot")
+	}
+}
+
+func TestReloadIfChangedComparesTheContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capitals.txt")
+	writeCapitals(t, path, "Seoul\n1\n")
+	r, err := NewReloader(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Touching the file, or writing the same content again, is not a change
+	later := info.ModTime().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	writeCapitals(t, path, "Seoul\n1\n")
+	if err := r.reloadIfChanged(); err != nil || r.Snapshot().Version != 1 {
+		t.Errorf("unchanged content: %v, version %d", err, r.Snapshot().Version)
+	}
+
+	// The same size and the same modification time, as a quick rewrite on a coarse clock would leave it
+	writeCapitals(t, path, "Seoul\n2\n")
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reloadIfChanged(); err != nil || r.Snapshot().Version != 2 || r.GetPopulation("Seoul") != 2 {
+		t.Errorf("rewrite of the same size: %v, version %d, Seoul at %d", err, r.Snapshot().Version, r.GetPopulation("Seoul"))
+	}
+
+	// A broken file is reported on every check until it is fixed, and the last good data stays
+	writeCapitals(t, path, "Seoul\n-2\n")
+	for i := 0; i < 2; i++ {
+		if err := r.reloadIfChanged(); err == nil || r.GetPopulation("Seoul") != 2 {
+			t.Errorf("check %d of a broken file: %v, Seoul at %d", i, err, r.GetPopulation("Seoul"))
+		}
+	}
+	writeCapitals(t, path, "Seoul\n3\n")
+	if err := r.reloadIfChanged(); err != nil || r.Snapshot().Version != 3 {
+		t.Errorf("after the fix: %v, version %d", err, r.Snapshot().Version)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capitals.txt")
+	writeCapitals(t, path, "Seoul\n1\n")
+	r, err := NewReloader(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots, errs := make(chan *Snapshot, 10), make(chan error, 10)
+	r.Subscribe(func(s *Snapshot) { snapshots <- s })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Watch(ctx, time.Millisecond, func(err error) { errs <- err })
+		close(done)
+	}()
+
+	writeCapitals(t, path, "Seoul\n2\n")
+	select {
+	case s := <-snapshots:
+		if s.Version != 2 || s.GetPopulation("Seoul") != 2 {
+			t.Errorf("Watch reloaded version %d, %v", s.Version, s.Capitals())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not pick up the change")
+	}
+
+	writeCapitals(t, path, "Seoul\n")
+	select {
+	case err := <-errs:
+		if _, ok := err.(*FormatError); !ok {
+			t.Errorf("Watch reported %v, want a FormatError", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not report the broken file")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not return when the context was cancelled")
+	}
+}
@@ -0,0 +1,77 @@
+package lazy
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+A sync.Once and a package level instance give us a lazy, thread safe singleton, but once it is created it can never
+be replaced. That is the testability problem from Problem_With_Singleton: every test ends up talking to the real database.
+
+Lazy[T] keeps the good parts and adds ways to swap the value:
+  - Get creates the value on first use, in a way that is safe for concurrent callers. Creation can fail, and the error
+    is kept and returned on every call, just like the value: a failed creation is not retried, only Reset forgets it.
+  - WithValue and From scope a different value to a context.Context, so one request or one test can use a fake
+    without affecting anybody else.
+  - Override and Reset replace or forget the value for everyone. They are only compiled with the "lazytest" build tag
+    (go test -race -tags lazytest ./Design_Patterns/4_Singleton/E_Lazy runs their tests too), so production code
+    cannot reach them. See testing_hooks.go.
+
+A package level Lazy variable is the registration of a service, and the package that declares it is its registry.
+*/
+
+type result[T any] struct {
+	value T
+	err   error
+}
+
+type Lazy[T any] struct {
+	init func() (T, error)
+	mu   sync.Mutex   // Held while the value is created, so init runs only once
+	done atomic.Value // *result[T], or a nil *result[T] while there is no value yet
+}
+
+// New registers a value that is created by init the first time it is needed.
+func New[T any](init func() (T, error)) *Lazy[T] {
+	return &Lazy[T]{init: init}
+}
+
+func (l *Lazy[T]) load() *result[T] {
+	r, _ := l.done.Load().(*result[T])
+	return r
+}
+
+// Get returns the value, creating it on the first call. Later calls return the same value, or the same error.
+func (l *Lazy[T]) Get() (T, error) {
+	if r := l.load(); r != nil {
+		return r.value, r.err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r := l.load(); r != nil {
+		return r.value, r.err // Someone else created it while we were waiting
+	}
+	value, err := l.init()
+	l.done.Store(&result[T]{value, err})
+	return value, err
+}
+
+// Each Lazy has its own key, so values scoped to different services never collide.
+type scopeKey[T any] struct {
+	lazy *Lazy[T]
+}
+
+// WithValue returns a context in which From returns v instead of the shared value.
+func (l *Lazy[T]) WithValue(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, scopeKey[T]{l}, v)
+}
+
+// From returns the value scoped to ctx, falling back to Get when there is none.
+func (l *Lazy[T]) From(ctx context.Context) (T, error) {
+	if v, ok := ctx.Value(scopeKey[T]{l}).(T); ok {
+		return v, nil
+	}
+	return l.Get()
+}
@@ -0,0 +1,113 @@
+package lazy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type service struct {
+	id int64
+}
+
+// counting returns a Lazy whose init counts its calls, and fails when err is set.
+func counting(calls *int64, err error) *Lazy[*service] {
+	return New(func() (*service, error) {
+		n := atomic.AddInt64(calls, 1)
+		time.Sleep(10 * time.Millisecond) // Long enough for the other callers to pile up behind the first one
+		if err != nil {
+			return nil, err
+		}
+		return &service{n}, nil
+	})
+}
+
+// Run with -race: the first calls are concurrent, and only one of them may run init.
+func TestConcurrentFirstGetRunsInitOnce(t *testing.T) {
+	var calls int64
+	l := counting(&calls, nil)
+
+	const callers = 50
+	results := make([]*service, callers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			s, err := l.Get()
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = s
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("init ran %d times", calls)
+	}
+	for i, s := range results {
+		if s != results[0] {
+			t.Fatalf("caller %d got %p, caller 0 got %p", i, s, results[0])
+		}
+	}
+}
+
+func TestGetKeepsTheError(t *testing.T) {
+	errDown := errors.New("database is down")
+	var calls int64
+	l := counting(&calls, errDown)
+
+	for i := 0; i < 3; i++ {
+		if s, err := l.Get(); s != nil || !errors.Is(err, errDown) {
+			t.Errorf("call %d: Get() = %v, %v, want the error of init", i, s, err)
+		}
+	}
+	// Failing is not retried: every caller gets the same answer until the program restarts, or Reset in tests
+	if calls != 1 {
+		t.Errorf("init ran %d times, want once even though it failed", calls)
+	}
+}
+
+func TestFromPrefersTheScopedValue(t *testing.T) {
+	var calls, otherCalls int64
+	l, other := counting(&calls, nil), counting(&otherCalls, nil)
+	fake, inner := &service{-1}, &service{-2}
+
+	ctx := l.WithValue(context.Background(), fake)
+	if s, err := l.From(ctx); s != fake || err != nil {
+		t.Errorf("From(scoped) = %v, %v, want the fake", s, err)
+	}
+	if calls != 0 {
+		t.Error("From ran init although a value was scoped to the context")
+	}
+
+	// Only the Lazy the value was scoped with sees it, although both hold the same type
+	if s, err := other.From(ctx); s == fake || err != nil || otherCalls != 1 {
+		t.Errorf("the other Lazy got %v, %v", s, err)
+	}
+
+	// A nested scope wins, and leaves the outer one alone
+	nested := l.WithValue(ctx, inner)
+	if s, _ := l.From(nested); s != inner {
+		t.Errorf("From(nested) = %v, want the inner value", s)
+	}
+	if s, _ := l.From(ctx); s != fake {
+		t.Errorf("From(outer) = %v after nesting, want the fake", s)
+	}
+
+	// Without a scope, From is Get, and scoping never changed the shared value
+	shared, err := l.From(context.Background())
+	if err != nil || shared == fake || shared == inner || calls != 1 {
+		t.Errorf("From(background) = %v, %v after %d calls to init", shared, err, calls)
+	}
+	if s, _ := l.Get(); s != shared {
+		t.Errorf("Get() = %v, want the same %v as From", s, shared)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build lazytest
+
+package lazy
+
+/*
+These hooks change the value for every caller at once, which is what a test needs for code that calls Get directly.
+They are left out of normal builds, so only code compiled with "-tags lazytest" can replace a production service.
+Tests that override the same Lazy must not run in parallel; scope the value to a context instead when they do.
+*/
+
+// Override makes Get return v from now on, and returns a function that puts the previous state back.
+func (l *Lazy[T]) Override(v T) (restore func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	previous := l.load()
+	l.done.Store(&result[T]{value: v})
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.done.Store(previous)
+	}
+}
+
+// Reset forgets the value and the error, so the next call to Get runs init again.
+func (l *Lazy[T]) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.done.Store((*result[T])(nil))
+}
@@ -0,0 +1,81 @@
+//go:build lazytest
+
+package lazy
+
+import (
+	"errors"
+	"testing"
+)
+
+/*
+These tests only build with the hooks, so they need the tag as well:
+
+	go test -race -tags lazytest ./Design_Patterns/4_Singleton/E_Lazy
+
+Without the tag, go test runs the tests of lazy_test.go only.
+*/
+
+func TestOverrideAndRestore(t *testing.T) {
+	var calls int64
+	l := counting(&calls, nil)
+	fake := &service{-1}
+
+	// Overriding before the first Get means init never runs while the override is in place
+	restore := l.Override(fake)
+	if s, err := l.Get(); s != fake || err != nil || calls != 0 {
+		t.Errorf("Get() = %v, %v with %d calls to init, want the fake", s, err, calls)
+	}
+	restore()
+	real, err := l.Get()
+	if err != nil || real == fake || calls != 1 {
+		t.Fatalf("after restoring, Get() = %v, %v with %d calls to init, want a real value", real, err, calls)
+	}
+
+	// Overriding an existing value puts that same value back
+	restore = l.Override(fake)
+	if s, _ := l.Get(); s != fake {
+		t.Errorf("Get() = %v, want the fake", s)
+	}
+	restore()
+	if s, _ := l.Get(); s != real || calls != 1 {
+		t.Errorf("after restoring, Get() = %v with %d calls to init, want the first real value", s, calls)
+	}
+}
+
+func TestResetRetriesAfterAnError(t *testing.T) {
+	errDown := errors.New("database is down")
+	failing := true
+	var calls int
+	l := New(func() (*service, error) {
+		calls++
+		if failing {
+			return nil, errDown
+		}
+		return &service{int64(calls)}, nil
+	})
+
+	if _, err := l.Get(); !errors.Is(err, errDown) {
+		t.Fatalf("Get() = %v, want the error", err)
+	}
+	failing = false
+	if _, err := l.Get(); !errors.Is(err, errDown) || calls != 1 {
+		t.Fatalf("Get() = %v after %d calls, want the kept error", err, calls)
+	}
+	l.Reset()
+	if s, err := l.Get(); err != nil || s.id != 2 {
+		t.Errorf("after Reset, Get() = %v, %v, want init to run again", s, err)
+	}
+
+	// Overriding a kept error also hides it, and restoring brings it back
+	l.Reset()
+	failing = true
+	l.Get()
+	restore := l.Override(&service{-1})
+	if _, err := l.Get(); err != nil {
+		t.Errorf("Get() = %v with an override, want no error", err)
+	}
+	restore()
+	if _, err := l.Get(); !errors.Is(err, errDown) {
+		t.Errorf("after restoring, Get() = %v, want the kept error", err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	datasource "github.com/Germanchrystan/design_patterns_course/Design_Patterns/4_Singleton/D_Data_Source"
+)
+
+/*
+	Once GetSingletonDatabase has loaded the capitals, the data can never change.
+	But the single instance does not have to hold the data itself: here it holds a Reloader,
+	which swaps in a new snapshot every time the file changes on disk.
+	Everybody still shares the same instance, and so everybody sees the new data as soon as it is loaded.
+*/
+
+type singletonDatabase struct {
+	*datasource.Reloader
+}
+
+var once sync.Once
+var instance *singletonDatabase
+var initErr error
+
+// The options, like the reload interval, have to be set before the first call to GetSingletonDatabase.
+var options datasource.Options
+var reloadInterval = time.Second
+
+// Watching goes on for as long as the program runs, unless stopWatching is called.
+var watching, stopWatching = context.WithCancel(context.Background())
+
+func GetSingletonDatabase() (*singletonDatabase, error) {
+	once.Do(func() {
+		reloader, err := datasource.NewReloader(options)
+		if err != nil {
+			initErr = fmt.Errorf("loading capitals: %w", err)
+			return
+		}
+		instance = &singletonDatabase{reloader}
+		go reloader.Watch(watching, reloadInterval, func(err error) {
+			fmt.Println("reloading capitals:", err)
+		})
+	})
+	return instance, initErr
+}
+
+func main() {
+	// We work on a copy of the file, to show what happens when it changes
+	dir, err := os.MkdirTemp("", "capitals")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, datasource.DefaultFile)
+	if err := os.WriteFile(path, datasource.DefaultData(), 0644); err != nil {
+		fmt.Println(err)
+		return
+	}
+	options = datasource.Options{Path: path}
+	reloadInterval = 50 * time.Millisecond
+
+	db, err := GetSingletonDatabase()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	snapshot := db.Snapshot()
+	fmt.Println("version", snapshot.Version, "Seoul =", snapshot.GetPopulation("Seoul"))
+
+	reloaded := make(chan *datasource.Snapshot, 1)
+	unsubscribe := db.Subscribe(func(s *datasource.Snapshot) {
+		reloaded <- s
+	})
+	defer unsubscribe()
+	defer stopWatching()
+
+	if err := os.WriteFile(path, []byte("Seoul\n9700000\n"), 0644); err != nil {
+		fmt.Println(err)
+		return
+	}
+	select {
+	case s := <-reloaded:
+		fmt.Println("version", s.Version, "Seoul =", db.GetPopulation("Seoul"), "loaded at", s.LoadedAt.Format(time.RFC3339))
+	case <-time.After(5 * time.Second):
+		fmt.Println("the file was not reloaded")
+	}
+
+	// The snapshot taken before the reload still has the old data
+	fmt.Println("old snapshot: Seoul =", snapshot.GetPopulation("Seoul"))
+}
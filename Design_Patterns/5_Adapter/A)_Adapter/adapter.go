@@ -2,6 +2,10 @@ package main
 
 import (
 	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -51,8 +55,9 @@ type RasterImage interface {
 	GetPoints() []Point
 }
 
+// The drawing starts at (0, 0), unless there are points with negative coordinates, which move it further up and left.
 func DrawPoints(owner RasterImage) string {
-	maxX, maxY := 0, 0
+	minX, minY, maxX, maxY := 0, 0, 0, 0
 	points := owner.GetPoints()
 	for _, pixel := range points {
 		if pixel.X > maxX {
@@ -61,21 +66,27 @@ func DrawPoints(owner RasterImage) string {
 		if pixel.Y > maxY {
 			maxY = pixel.Y
 		}
+		if pixel.X < minX {
+			minX = pixel.X
+		}
+		if pixel.Y < minY {
+			minY = pixel.Y
+		}
 	}
 
-	maxX += 1
-	maxY += 1
+	width := maxX - minX + 1
+	height := maxY - minY + 1
 
-	data := make([][]rune, maxY)
-	for i := 0; i < maxY; i++ {
-		data[i] = make([]rune, maxX)
+	data := make([][]rune, height)
+	for i := 0; i < height; i++ {
+		data[i] = make([]rune, width)
 		for j := range data[i] {
 			data[i][j] = ' '
 		}
 	}
 
 	for _, point := range points {
-		data[point.Y][point.X] = '*'
+		data[point.Y-minY][point.X-minX] = '*'
 	}
 
 	b := strings.Builder{}
@@ -90,31 +101,23 @@ func DrawPoints(owner RasterImage) string {
 // We need an adapter to take the vector image and convert it to a point image
 type vectorToRasterAdapter struct {
 	points []Point
-}
-
-func minmax(a, b int) (int, int) {
-	if a < b {
-		return a, b
-	} else {
-		return b, a
-	}
+	// Only filled in when the adapter anti-aliases, see rasterize.go
+	antiAlias bool
+	shaded    []ShadedPoint
 }
 
 // This is the adapter
 func (a *vectorToRasterAdapter) AddLine(line Line) {
 	// Here we need to decompose a line and set up a bunch of points
-	left, right := minmax(line.X1, line.X2)
-	top, bottom := minmax(line.Y1, line.Y2)
-	dx := right - left
-	dy := line.Y2 - line.Y1
-
-	if dx == 0 {
-		for y := top; y <= bottom; y++ {
-			a.points = append(a.points, Point{left, y})
-		}
-	} else if dy == 0 {
-		for x := left; x <= right; x++ {
-			a.points = append(a.points, Point{x, top})
+	if !a.antiAlias {
+		a.points = append(a.points, rasterizeLine(line)...)
+		return
+	}
+	for _, p := range rasterizeLineAA(line) {
+		a.shaded = append(a.shaded, p)
+		// Plain points are the ones covered at least halfway
+		if p.Coverage >= 0.5 {
+			a.points = append(a.points, p.Point)
 		}
 	}
 }
@@ -123,6 +126,17 @@ func (v vectorToRasterAdapter) GetPoints() []Point {
 	return v.points
 }
 
+func (v vectorToRasterAdapter) GetShadedPoints() []ShadedPoint {
+	if !v.antiAlias {
+		shaded := make([]ShadedPoint, len(v.points))
+		for i, p := range v.points {
+			shaded[i] = ShadedPoint{p, 1}
+		}
+		return shaded
+	}
+	return v.shaded
+}
+
 func VectorToRaster(vi *VectorImage) RasterImage {
 	adapter := vectorToRasterAdapter{}
 
@@ -133,9 +147,57 @@ func VectorToRaster(vi *VectorImage) RasterImage {
 	return adapter
 }
 
+// VectorToShadedRaster works like VectorToRaster, but anti-aliases the lines.
+func VectorToShadedRaster(vi *VectorImage) ShadedRasterImage {
+	adapter := vectorToRasterAdapter{antiAlias: true}
+
+	for _, line := range vi.Lines {
+		adapter.AddLine(line)
+	}
+
+	return adapter
+}
+
 func main() {
 	rc := NewRectangle(6, 4)
 	a := VectorToRaster(rc)
 
 	fmt.Println(DrawPoints(a))
+
+	// Lines of any slope are rasterized, not just horizontal and vertical ones
+	triangle := &VectorImage{[]Line{{0, 0, 12, 4}, {12, 4, 3, 9}, {3, 9, 0, 0}}}
+	fmt.Println(DrawPoints(VectorToRaster(triangle)))
+	fmt.Println(DrawShadedPoints(VectorToShadedRaster(triangle)))
+
+	// The same images can be exported, here on a larger canvas with the triangle moved into its middle
+	canvas := Canvas{Width: 24, Height: 16, Offset: Point{6, 3}, Foreground: color.NRGBA{0, 0, 128, 255}}
+	exports := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"triangle.png", func(w io.Writer) error { return WritePNG(w, VectorToShadedRaster(triangle), canvas) }},
+		{"triangle.ppm", func(w io.Writer) error { return WritePPM(w, VectorToRaster(triangle), canvas) }},
+		{"triangle.svg", func(w io.Writer) error { return WriteSVG(w, triangle, canvas) }},
+	}
+	for _, e := range exports {
+		if err := writeFile(filepath.Join(os.TempDir(), e.name), e.write); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	// Points with negative coordinates are no longer lost
+	fmt.Println(DrawPoints(VectorToRaster(&VectorImage{[]Line{{-3, -2, 3, 2}}})))
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	fmt.Println("wrote", path)
+	return f.Close()
 }
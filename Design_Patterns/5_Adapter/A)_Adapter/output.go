@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+/*
+	DrawPoints is fine for a preview in the terminal, but reports need real images.
+	The functions below are adapters too: they take the same RasterImage and write it as an image.Image, a PNG or a PPM,
+	while WriteSVG takes the original VectorImage, since SVG is a vector format itself.
+
+	All of them draw on a Canvas. The point (x, y) ends up at (x+Offset.X, y+Offset.Y), so points with negative
+	coordinates can be moved into view, and whatever still falls outside of the canvas is clipped.
+	A canvas without a Width fits its width around the image instead, see Fit, and likewise without a Height.
+	Each side is settled on its own: a side with a size keeps the Offset along it, while fitting a side also
+	replaces the Offset along it, since finding the offset that brings the points into view is the whole point.
+	So Canvas{Height: 32} is as wide as the image and 32 pixels high, with the points at the top.
+*/
+
+type Canvas struct {
+	Width, Height int
+	Offset        Point
+	// Nil colours mean black lines on white.
+	Foreground, Background color.Color
+}
+
+// Fit returns a canvas just large enough for the points, with an offset that moves the smallest ones to zero.
+// Only the size and the offset are set, so the colours of c are kept.
+func (c Canvas) Fit(points []Point) Canvas {
+	if len(points) == 0 {
+		c.Width, c.Height, c.Offset = 0, 0, Point{}
+		return c
+	}
+	minX, minY, maxX, maxY := points[0].X, points[0].Y, points[0].X, points[0].Y
+	for _, p := range points[1:] {
+		if p.X < minX {
+			minX = p.X
+		}
+		if p.X > maxX {
+			maxX = p.X
+		}
+		if p.Y < minY {
+			minY = p.Y
+		}
+		if p.Y > maxY {
+			maxY = p.Y
+		}
+	}
+	c.Width, c.Height = maxX-minX+1, maxY-minY+1
+	c.Offset = Point{-minX, -minY}
+	return c
+}
+
+func (c Canvas) resolve(points []Point) Canvas {
+	fitted := c.Fit(points)
+	if c.Width <= 0 {
+		c.Width, c.Offset.X = fitted.Width, fitted.Offset.X
+	}
+	if c.Height <= 0 {
+		c.Height, c.Offset.Y = fitted.Height, fitted.Offset.Y
+	}
+	if c.Foreground == nil {
+		c.Foreground = color.Black
+	}
+	if c.Background == nil {
+		c.Background = color.White
+	}
+	return c
+}
+
+// Endpoints are all a canvas needs to fit around a vector image, since lines are straight.
+func endpoints(vi *VectorImage) []Point {
+	points := make([]Point, 0, 2*len(vi.Lines))
+	for _, l := range vi.Lines {
+		points = append(points, Point{l.X1, l.Y1}, Point{l.X2, l.Y2})
+	}
+	return points
+}
+
+// ToImage draws the points on a new image. When owner is a ShadedRasterImage, every point is blended
+// between the background and the foreground by its coverage.
+func ToImage(owner RasterImage, c Canvas) *image.NRGBA {
+	points := owner.GetPoints()
+	c = c.resolve(points)
+	img := image.NewNRGBA(image.Rect(0, 0, c.Width, c.Height))
+	bg := color.NRGBAModel.Convert(c.Background).(color.NRGBA)
+	fg := color.NRGBAModel.Convert(c.Foreground).(color.NRGBA)
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			img.SetNRGBA(x, y, bg)
+		}
+	}
+
+	shaded, ok := owner.(ShadedRasterImage)
+	if !ok {
+		for _, p := range points {
+			img.SetNRGBA(p.X+c.Offset.X, p.Y+c.Offset.Y, fg) // Outside of the bounds does nothing
+		}
+		return img
+	}
+	// Where shaded points overlap, the highest coverage wins, as in DrawShadedPoints
+	coverage := map[Point]float64{}
+	for _, p := range shaded.GetShadedPoints() {
+		if p.Coverage > coverage[p.Point] {
+			coverage[p.Point] = p.Coverage
+		}
+	}
+	for p, cov := range coverage {
+		img.SetNRGBA(p.X+c.Offset.X, p.Y+c.Offset.Y, blend(bg, fg, cov))
+	}
+	return img
+}
+
+func blend(bg, fg color.NRGBA, t float64) color.NRGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5)
+	}
+	return color.NRGBA{mix(bg.R, fg.R), mix(bg.G, fg.G), mix(bg.B, fg.B), mix(bg.A, fg.A)}
+}
+
+func WritePNG(w io.Writer, owner RasterImage, c Canvas) error {
+	return png.Encode(w, ToImage(owner, c))
+}
+
+// WritePPM writes a binary PPM (P6). The format has no transparency, so alpha is ignored.
+func WritePPM(w io.Writer, owner RasterImage, c Canvas) error {
+	img := ToImage(owner, c)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P6\n%d %d\n255\n", img.Rect.Dx(), img.Rect.Dy())
+	for i := 0; i < len(img.Pix); i += 4 {
+		bw.Write(img.Pix[i : i+3])
+	}
+	return bw.Flush()
+}
+
+/*
+	WriteSVG draws every line from the centre of its first pixel to the centre of its last one,
+	so the SVG lines up with the rasterized images on the same canvas.
+*/
+
+func WriteSVG(w io.Writer, vi *VectorImage, c Canvas) error {
+	c = c.resolve(endpoints(vi))
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		c.Width, c.Height, c.Width, c.Height)
+	fmt.Fprintf(bw, `  <rect width="100%%" height="100%%" fill="%s"/>`+"\n", svgColor(c.Background))
+	fmt.Fprintf(bw, `  <g stroke="%s" stroke-width="1" stroke-linecap="square">`+"\n", svgColor(c.Foreground))
+	for _, l := range vi.Lines {
+		fmt.Fprintf(bw, `    <line x1="%g" y1="%g" x2="%g" y2="%g"/>`+"\n",
+			float64(l.X1+c.Offset.X)+0.5, float64(l.Y1+c.Offset.Y)+0.5,
+			float64(l.X2+c.Offset.X)+0.5, float64(l.Y2+c.Offset.Y)+0.5)
+	}
+	bw.WriteString("  </g>\n</svg>\n")
+	return bw.Flush()
+}
+
+func svgColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3g)", n.R, n.G, n.B, float64(n.A)/255)
+}
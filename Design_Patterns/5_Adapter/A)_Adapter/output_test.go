@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	white = color.NRGBA{255, 255, 255, 255}
+	black = color.NRGBA{0, 0, 0, 255}
+	red   = color.NRGBA{255, 0, 0, 255}
+)
+
+// pixels lists the image row by row, with one letter for every colour in key and '?' for any other.
+func pixels(img *image.NRGBA, key map[color.NRGBA]byte) string {
+	var b strings.Builder
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if r, ok := key[img.NRGBAAt(x, y)]; ok {
+				b.WriteByte(r)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+var blackOnWhite = map[color.NRGBA]byte{white: '.', black: '#'}
+
+func TestToImageOffsetAndClipping(t *testing.T) {
+	ps := points{{0, 0}, {2, 1}, {-1, 0}, {-3, -3}, {3, 0}, {1, 2}}
+	img := ToImage(ps, Canvas{Width: 4, Height: 2, Offset: Point{1, 0}})
+
+	// (-1, 0) is moved into view, (3, 0) is moved out on the right, and the others are outside anyway
+	want := "##..\n...#\n"
+	if img.Rect != image.Rect(0, 0, 4, 2) {
+		t.Fatalf("the image is %v, want the size of the canvas", img.Rect)
+	}
+	if got := pixels(img, blackOnWhite); got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+}
+
+func TestFittedCanvasMatchesAnExplicitOne(t *testing.T) {
+	ps := points{{-2, 1}, {3, -1}, {0, 0}}
+	fitted := Canvas{}.Fit(ps)
+	if want := (Canvas{Width: 6, Height: 3, Offset: Point{2, 1}}); fitted != want {
+		t.Fatalf("Fit() = %+v, want %+v", fitted, want)
+	}
+
+	auto := ToImage(ps, Canvas{})
+	explicit := ToImage(ps, Canvas{Width: 6, Height: 3, Offset: Point{2, 1}})
+	if auto.Rect != explicit.Rect || !bytes.Equal(auto.Pix, explicit.Pix) {
+		t.Errorf("the fitted canvas drew\n%sthe explicit one\n%s", pixels(auto, blackOnWhite), pixels(explicit, blackOnWhite))
+	}
+	if got, want := pixels(auto, blackOnWhite), ".....#\n..#...\n#.....\n"; got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+
+	// Fit keeps the colours, and an empty image fits on an empty canvas
+	if c := (Canvas{Foreground: red, Offset: Point{5, 5}}).Fit(nil); c != (Canvas{Foreground: red}) {
+		t.Errorf("Fit(nil) = %+v", c)
+	}
+	if img := ToImage(points{}, Canvas{}); !img.Rect.Empty() {
+		t.Errorf("an empty image was drawn on %v", img.Rect)
+	}
+}
+
+func TestResolveFitsEachSideOnItsOwn(t *testing.T) {
+	ps := []Point{{-2, 1}, {3, -1}}
+	tests := []struct {
+		name   string
+		canvas Canvas
+		want   Canvas
+	}{
+		{"explicit", Canvas{Width: 9, Height: 8, Offset: Point{4, 5}}, Canvas{Width: 9, Height: 8, Offset: Point{4, 5}}},
+		{"no width", Canvas{Height: 8, Offset: Point{4, 5}}, Canvas{Width: 6, Height: 8, Offset: Point{2, 5}}},
+		{"no height", Canvas{Width: 9, Offset: Point{4, 5}}, Canvas{Width: 9, Height: 3, Offset: Point{4, 1}}},
+		{"no size", Canvas{Offset: Point{4, 5}}, Canvas{Width: 6, Height: 3, Offset: Point{2, 1}}},
+		{"negative size", Canvas{Width: -1, Height: 8}, Canvas{Width: 6, Height: 8, Offset: Point{2, 0}}},
+	}
+	for _, tt := range tests {
+		got := tt.canvas.resolve(ps)
+		tt.want.Foreground, tt.want.Background = color.Black, color.White
+		if got != tt.want {
+			t.Errorf("%s: resolve() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToImageColours(t *testing.T) {
+	ps := points{{0, 0}}
+	transparent := color.NRGBA{}
+	tests := []struct {
+		name   string
+		canvas Canvas
+		want   []color.NRGBA // The point, then the background
+	}{
+		{"default", Canvas{Width: 2, Height: 1}, []color.NRGBA{black, white}},
+		{"custom", Canvas{Width: 2, Height: 1, Foreground: red, Background: transparent}, []color.NRGBA{red, transparent}},
+		{"only the foreground", Canvas{Width: 2, Height: 1, Foreground: color.Gray{128}}, []color.NRGBA{{128, 128, 128, 255}, white}},
+	}
+	for _, tt := range tests {
+		img := ToImage(ps, tt.canvas)
+		if got := []color.NRGBA{img.NRGBAAt(0, 0), img.NRGBAAt(1, 0)}; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToImageBlendsShadedPoints(t *testing.T) {
+	shaded := shadedPoints{
+		{Point{0, 0}, 1},
+		{Point{1, 0}, 0.25},
+		{Point{2, 0}, 0.5},
+		{Point{2, 0}, 0.75}, // Overlapping points take the highest coverage
+		{Point{2, 0}, 0.1},
+	}
+	img := ToImage(shaded, Canvas{Width: 4, Height: 1, Foreground: color.NRGBA{0, 0, 200, 255}, Background: color.NRGBA{255, 255, 255, 0}})
+	want := []color.NRGBA{
+		{0, 0, 200, 255},
+		{191, 191, 241, 64}, // A quarter of the way from the background to the foreground, rounded
+		{64, 64, 214, 191},
+		{255, 255, 255, 0},
+	}
+	for x, w := range want {
+		if got := img.NRGBAAt(x, 0); got != w {
+			t.Errorf("pixel %d is %v, want %v", x, got, w)
+		}
+	}
+
+	// Without shading, the same image is drawn from GetPoints
+	plain := VectorToRaster(&VectorImage{[]Line{{0, 0, 3, 1}}})
+	if got := pixels(ToImage(plain, Canvas{}), blackOnWhite); got != "##..\n..##\n" {
+		t.Errorf("got\n%s", got)
+	}
+}
+
+func TestWritePPM(t *testing.T) {
+	var buf bytes.Buffer
+	canvas := Canvas{Width: 3, Height: 2, Foreground: color.NRGBA{1, 2, 3, 255}, Background: color.NRGBA{10, 20, 30, 0}}
+	if err := WritePPM(&buf, points{{0, 0}, {2, 1}}, canvas); err != nil {
+		t.Fatal(err)
+	}
+	want := "P6\n3 2\n255\n" +
+		"\x01\x02\x03" + "\x0a\x14\x1e" + "\x0a\x14\x1e" + // The transparent background keeps its colour
+		"\x0a\x14\x1e" + "\x0a\x14\x1e" + "\x01\x02\x03"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	shaded := VectorToShadedRaster(&VectorImage{[]Line{{0, 0, 5, 2}}})
+	canvas := Canvas{Foreground: red}
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, shaded, canvas); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ToImage(shaded, canvas)
+	if decoded.Bounds() != want.Rect {
+		t.Fatalf("the PNG is %v, want %v", decoded.Bounds(), want.Rect)
+	}
+	for y := 0; y < want.Rect.Dy(); y++ {
+		for x := 0; x < want.Rect.Dx(); x++ {
+			if got := color.NRGBAModel.Convert(decoded.At(x, y)); got != want.NRGBAAt(x, y) {
+				t.Errorf("pixel (%d, %d) is %v in the PNG, want %v", x, y, got, want.NRGBAAt(x, y))
+			}
+		}
+	}
+}
+
+func TestWriteSVGGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		image  *VectorImage
+		canvas Canvas
+	}{
+		{"rectangle", NewRectangle(4, 3), Canvas{}},
+		{"negative", &VectorImage{[]Line{{-3, -2, 4, 1}, {0, 0, 0, 2}}}, Canvas{}},
+		{"offset_and_colours", &VectorImage{[]Line{{0, 0, 12, 4}, {12, 4, 3, 9}}},
+			Canvas{Width: 24, Height: 16, Offset: Point{6, 3}, Foreground: color.NRGBA{0, 0, 128, 255}, Background: color.NRGBA{255, 255, 0, 128}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteSVG(&buf, tt.image, tt.canvas); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", tt.name+".svg")
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("SVG differs from %s\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
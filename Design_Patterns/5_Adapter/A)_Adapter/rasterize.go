@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strings"
+)
+
+/*
+	AddLine used to handle only horizontal and vertical lines, and silently dropped everything else.
+	rasterizeLine works for any slope with Bresenham's algorithm: it walks from (X1, Y1) to (X2, Y2) one pixel at a time,
+	and keeps track of how far the ideal line is from the pixels it picked, to decide when to step along each axis.
+	Only integer additions are needed, so there are no rounding errors.
+*/
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func sign(a int) int {
+	switch {
+	case a < 0:
+		return -1
+	case a > 0:
+		return 1
+	}
+	return 0
+}
+
+func rasterizeLine(line Line) []Point {
+	x, y := line.X1, line.Y1
+	dx, dy := abs(line.X2-line.X1), -abs(line.Y2-line.Y1)
+	sx, sy := sign(line.X2-line.X1), sign(line.Y2-line.Y1)
+	err := dx + dy
+
+	var points []Point
+	for {
+		points = append(points, Point{x, y})
+		if x == line.X2 && y == line.Y2 {
+			return points
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y += sy
+		}
+	}
+}
+
+/*
+	A pixel is either on or off, so diagonal lines look like staircases.
+	With anti-aliasing, every pixel the line passes near gets a coverage value between 0 and 1,
+	and a renderer can draw it as a shade instead. rasterizeLineAA uses Xiaolin Wu's algorithm:
+	for every step along the longer axis, the line falls between two pixels of the other axis,
+	and it is shared between them in proportion to how close it is to each.
+*/
+
+// ShadedPoint is a point with the fraction of it that is covered by the line, from 0 to 1.
+type ShadedPoint struct {
+	Point
+	Coverage float64
+}
+
+// A ShadedRasterImage also knows how much of each of its points is covered.
+type ShadedRasterImage interface {
+	RasterImage
+	GetShadedPoints() []ShadedPoint
+}
+
+// floorDiv rounds towards minus infinity, unlike the / operator, which rounds towards zero.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func rasterizeLineAA(line Line) []ShadedPoint {
+	x0, y0, x1, y1 := line.X1, line.Y1, line.X2, line.Y2
+	steep := abs(y1-y0) > abs(x1-x0)
+	if steep {
+		x0, y0, x1, y1 = y0, x0, y1, x1
+	}
+	if x0 > x1 {
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+	dx, dy := x1-x0, y1-y0
+
+	var points []ShadedPoint
+	plot := func(x, y int, coverage float64) {
+		if coverage == 0 {
+			return
+		}
+		if steep {
+			x, y = y, x
+		}
+		points = append(points, ShadedPoint{Point{x, y}, coverage})
+	}
+	if dx == 0 {
+		plot(x0, y0, 1) // A single point
+		return points
+	}
+
+	// The endpoints are integers, so the exact position of the line is y0 + dy*(x-x0)/dx,
+	// which splits into a whole pixel and a remainder without any floating point drift.
+	for x := x0; x <= x1; x++ {
+		num := dy * (x - x0)
+		y := y0 + floorDiv(num, dx)
+		frac := float64(num-floorDiv(num, dx)*dx) / float64(dx)
+		plot(x, y, 1-frac)
+		plot(x, y+1, frac)
+	}
+	return points
+}
+
+// From empty to full coverage.
+const shades = " .:-=+*#%@"
+
+// DrawShadedPoints draws every point as a character that is darker the more it is covered.
+// Where points overlap, the highest coverage wins. Points with negative coordinates move the drawing up and left.
+func DrawShadedPoints(owner ShadedRasterImage) string {
+	minX, minY, maxX, maxY := 0, 0, 0, 0
+	points := owner.GetShadedPoints()
+	for _, pixel := range points {
+		if pixel.X > maxX {
+			maxX = pixel.X
+		}
+		if pixel.Y > maxY {
+			maxY = pixel.Y
+		}
+		if pixel.X < minX {
+			minX = pixel.X
+		}
+		if pixel.Y < minY {
+			minY = pixel.Y
+		}
+	}
+
+	coverage := make([][]float64, maxY-minY+1)
+	for i := range coverage {
+		coverage[i] = make([]float64, maxX-minX+1)
+	}
+	for _, point := range points {
+		if c := &coverage[point.Y-minY][point.X-minX]; point.Coverage > *c {
+			*c = point.Coverage
+		}
+	}
+
+	b := strings.Builder{}
+	for _, row := range coverage {
+		for _, c := range row {
+			i := int(c*float64(len(shades)-1) + 0.5)
+			b.WriteByte(shades[i])
+		}
+		b.WriteRune('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// Every octant is a line starting at (4, 4), so that the drawings, which always include (0, 0), show the direction.
+var lines = []struct {
+	name string
+	line Line
+}{
+	{"octant1", Line{4, 4, 9, 6}},
+	{"octant2", Line{4, 4, 6, 9}},
+	{"octant3", Line{4, 4, 2, 9}},
+	{"octant4", Line{4, 4, 0, 6}},
+	{"octant5", Line{4, 4, 0, 2}},
+	{"octant6", Line{4, 4, 2, 0}},
+	{"octant7", Line{4, 4, 6, 0}},
+	{"octant8", Line{4, 4, 9, 2}},
+	{"horizontal", Line{1, 2, 6, 2}},
+	{"vertical", Line{2, 5, 2, 1}},
+	{"diagonal", Line{0, 0, 4, 4}},
+	{"zero_length", Line{3, 2, 3, 2}},
+	{"zero_length_negative", Line{-2, -1, -2, -1}},
+	{"negative", Line{-3, -2, 4, 1}},
+	{"negative_steep", Line{2, -4, -2, 3}},
+}
+
+type points []Point
+
+func (p points) GetPoints() []Point { return p }
+
+type shadedPoints []ShadedPoint
+
+func (p shadedPoints) GetPoints() []Point             { return nil }
+func (p shadedPoints) GetShadedPoints() []ShadedPoint { return p }
+
+// The rows are framed, so that the trailing spaces of a drawing survive editors that strip them.
+func framed(drawing string) string {
+	rows := strings.Split(strings.TrimSuffix(drawing, "\n"), "\n")
+	for i, row := range rows {
+		rows[i] = "|" + row + "|"
+	}
+	return strings.Join(rows, "\n") + "\n"
+}
+
+func TestRasterizeGolden(t *testing.T) {
+	for _, tt := range lines {
+		t.Run(tt.name, func(t *testing.T) {
+			l := tt.line
+			got := fmt.Sprintf("(%d, %d) to (%d, %d)\n\nrasterizeLine\n%s\nrasterizeLineAA\n%s",
+				l.X1, l.Y1, l.X2, l.Y2,
+				framed(DrawPoints(points(rasterizeLine(l)))),
+				framed(DrawShadedPoints(shadedPoints(rasterizeLineAA(l)))))
+
+			path := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("drawing differs from %s\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+// The drawings cannot show the order of the points, so the shape of the lists is checked separately.
+func TestRasterizeLinePoints(t *testing.T) {
+	for _, tt := range lines {
+		l := tt.line
+		ps := rasterizeLine(l)
+		if first, last := ps[0], ps[len(ps)-1]; first != (Point{l.X1, l.Y1}) || last != (Point{l.X2, l.Y2}) {
+			t.Errorf("%s: runs from %v to %v", tt.name, first, last)
+		}
+		if want := maxInt(abs(l.X2-l.X1), abs(l.Y2-l.Y1)) + 1; len(ps) != want {
+			t.Errorf("%s: %d points, want one for every step along the longer axis, %d", tt.name, len(ps), want)
+		}
+		for i := 1; i < len(ps); i++ {
+			if abs(ps[i].X-ps[i-1].X) > 1 || abs(ps[i].Y-ps[i-1].Y) > 1 {
+				t.Errorf("%s: gap between %v and %v", tt.name, ps[i-1], ps[i])
+			}
+		}
+	}
+}
+
+func TestRasterizeLineAACoverage(t *testing.T) {
+	for _, tt := range lines {
+		l := tt.line
+		steep := abs(l.Y2-l.Y1) > abs(l.X2-l.X1)
+		total := map[int]float64{} // Coverage for every step along the longer axis
+		for _, p := range rasterizeLineAA(l) {
+			if p.Coverage <= 0 || p.Coverage > 1 {
+				t.Errorf("%s: coverage %v at %v", tt.name, p.Coverage, p.Point)
+			}
+			if steep {
+				total[p.Y] += p.Coverage
+			} else {
+				total[p.X] += p.Coverage
+			}
+		}
+		if want := maxInt(abs(l.X2-l.X1), abs(l.Y2-l.Y1)) + 1; len(total) != want {
+			t.Errorf("%s: %d steps, want %d", tt.name, len(total), want)
+		}
+		for step, sum := range total {
+			if math.Abs(sum-1) > 1e-9 {
+				t.Errorf("%s: coverage at step %d adds up to %v, want 1", tt.name, step, sum)
+			}
+		}
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
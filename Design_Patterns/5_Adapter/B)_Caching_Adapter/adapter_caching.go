@@ -1,10 +1,9 @@
 package main
 
 import (
-	"crypto/md5"
-	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 )
 
 /*
@@ -82,6 +81,7 @@ func DrawPoints(owner RasterImage) string {
 }
 
 type vectorToRasterAdapter struct {
+	cache  *LineCache
 	points []Point
 }
 
@@ -93,21 +93,16 @@ func minmax(a, b int) (int, int) {
 	}
 }
 
-// Cache map
-var pointCache = map[[16]byte][]Point{}
+// DefaultCacheCapacity is the number of lines kept by the cache behind VectorToRaster.
+const DefaultCacheCapacity = 1024
+
+// Cache of the points of every line, shared by all adapters made by VectorToRaster. See line_cache.go
+var pointCache = NewLineCache(DefaultCacheCapacity)
 
 func (a *vectorToRasterAdapter) AddLineCached(line Line) {
-	// Before adding a line, we are going to calculate the lines hash.
-	hash := func(obj interface{}) [16]byte { // returns a md5 hash
-		bytes, _ := json.Marshal(obj)
-		return md5.Sum(bytes)
-	}
-	h := hash(line)
-	// If this line is already on our map, add them to the adapter
-	if pts, ok := pointCache[h]; ok {
-		for _, pt := range pts {
-			a.points = append(a.points, pt)
-		}
+	// The line itself is the key. If it is already in our cache, add its points to the adapter
+	if pts, ok := a.cache.Get(line); ok {
+		a.points = append(a.points, pts...)
 		return
 	}
 
@@ -116,20 +111,21 @@ func (a *vectorToRasterAdapter) AddLineCached(line Line) {
 	dx := right - left
 	dy := line.Y2 - line.Y1
 
+	// Only the points of this line go into the cache, not everything the adapter has collected so far
+	var pts []Point
 	if dx == 0 {
 		for y := top; y <= bottom; y++ {
-			a.points = append(a.points, Point{left, y})
+			pts = append(pts, Point{left, y})
 		}
 	} else if dy == 0 {
 		for x := left; x <= right; x++ {
-			a.points = append(a.points, Point{x, top})
+			pts = append(pts, Point{x, top})
 		}
 	}
 
 	// Adding a new line to the cache
-	pointCache[h] = a.points
-
-	fmt.Println("we have", len(a.points), "points")
+	a.cache.Add(line, pts)
+	a.points = append(a.points, pts...)
 }
 
 /*
@@ -142,8 +138,14 @@ func (v vectorToRasterAdapter) GetPoints() []Point {
 	return v.points
 }
 
+// VectorToRaster shares one cache of DefaultCacheCapacity lines with every other call.
 func VectorToRaster(vi *VectorImage) RasterImage {
-	adapter := vectorToRasterAdapter{}
+	return VectorToRasterCached(vi, pointCache)
+}
+
+// VectorToRasterCached uses the given cache instead, so a program can choose its capacity, or keep images apart.
+func VectorToRasterCached(vi *VectorImage, cache *LineCache) RasterImage {
+	adapter := vectorToRasterAdapter{cache: cache}
 
 	for _, line := range vi.Lines {
 		adapter.AddLineCached(line)
@@ -157,4 +159,22 @@ func main() {
 	a := VectorToRaster(rc)
 
 	fmt.Println(DrawPoints(a))
+
+	// Drawing the same rectangle again, from several goroutines at once, only hits the cache
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			VectorToRaster(rc)
+		}()
+	}
+	wg.Wait()
+	fmt.Printf("%+v\n", pointCache.Stats())
+
+	// A cache too small for the four sides of the rectangle keeps dropping the lines it is about to need
+	small := NewLineCache(2)
+	VectorToRasterCached(rc, small)
+	VectorToRasterCached(rc, small)
+	fmt.Printf("%+v\n", small.Stats())
 }
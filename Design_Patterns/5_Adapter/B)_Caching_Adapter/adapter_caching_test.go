@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVectorToRasterCachedHitsAddTheSamePoints(t *testing.T) {
+	rc := NewRectangle(6, 4)
+	cache := NewLineCache(DefaultCacheCapacity)
+
+	first := VectorToRasterCached(rc, cache).GetPoints()
+	second := VectorToRasterCached(rc, cache).GetPoints()
+	if len(first) != 20 || !reflect.DeepEqual(first, second) {
+		t.Errorf("%d points from the misses, %d from the hits, want the same 20", len(first), len(second))
+	}
+	if s := cache.Stats(); s.Hits != 4 || s.Misses != 4 || s.Size != 4 {
+		t.Errorf("Stats() = %+v, want a miss then a hit for every side", s)
+	}
+
+	// The points of an image are its own, so changing them leaves the cache alone
+	first[0] = Point{-1, -1}
+	if third := VectorToRasterCached(rc, cache).GetPoints(); !reflect.DeepEqual(third, second) {
+		t.Error("changing the points of one image changed the cached ones")
+	}
+}
+
+func TestVectorToRasterCachedWithASmallCache(t *testing.T) {
+	rc := NewRectangle(3, 3)
+	cache := NewLineCache(1)
+	want := VectorToRasterCached(rc, NewLineCache(DefaultCacheCapacity)).GetPoints()
+	for i := 0; i < 2; i++ {
+		if got := VectorToRasterCached(rc, cache).GetPoints(); !reflect.DeepEqual(got, want) {
+			t.Errorf("round %d: got %v, want %v", i, got, want)
+		}
+	}
+	// Every side pushes out the one before, so nothing is ever found
+	if s := cache.Stats(); s.Hits != 0 || s.Misses != 8 || s.Evictions != 7 || s.Size != 1 {
+		t.Errorf("Stats() = %+v", s)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+/*
+	The first version of the cache was a global map that grew forever, was not safe to use from several goroutines,
+	and hashed the JSON of every line with MD5 just to build a key.
+
+	A Line is a struct of four ints, so it can be the key of a map as it is: two lines are equal exactly when
+	their coordinates are, and there is nothing to marshal or hash on our side.
+	The cache keeps at most capacity lines. When it is full, the line that was used the longest time ago is dropped,
+	which a doubly linked list ordered from the most to the least recently used line makes cheap.
+*/
+
+type cacheEntry struct {
+	line   Line
+	points []Point
+}
+
+type LineCache struct {
+	mu       sync.Mutex // Even a lookup changes the order of the list, so there are no readers that can share a lock
+	capacity int
+	entries  map[Line]*list.Element
+	order    *list.List // Front is the most recently used
+
+	hits, misses, evictions uint64
+}
+
+type CacheStats struct {
+	Hits, Misses, Evictions uint64
+	Size, Capacity          int
+}
+
+// NewLineCache panics if capacity is not positive, as a cache that cannot hold anything is a programming error.
+func NewLineCache(capacity int) *LineCache {
+	if capacity <= 0 {
+		panic("line cache capacity must be positive")
+	}
+	return &LineCache{capacity: capacity, entries: map[Line]*list.Element{}, order: list.New()}
+}
+
+// Get returns the points of the line, if they are cached. The slice must not be changed.
+func (c *LineCache) Get(line Line) ([]Point, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[line]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).points, true
+}
+
+// Add caches the points of the line. The slice must not be changed afterwards, since it is shared with every Get.
+func (c *LineCache) Add(line Line, points []Point) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[line]; ok {
+		e.Value.(*cacheEntry).points = points
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[line] = c.order.PushFront(&cacheEntry{line, points})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).line)
+		c.evictions++
+	}
+}
+
+func (c *LineCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{c.hits, c.misses, c.evictions, c.order.Len(), c.capacity}
+}
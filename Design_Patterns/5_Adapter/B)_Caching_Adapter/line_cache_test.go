@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func line(n int) Line { return Line{n, 0, n, 1} }
+
+func pts(n int) []Point { return []Point{{n, 0}, {n, 1}} }
+
+// cached lists the keys from the most to the least recently used.
+func cached(c *LineCache) []Line {
+	var lines []Line
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		lines = append(lines, e.Value.(*cacheEntry).line)
+	}
+	return lines
+}
+
+func TestLineCacheEvictsTheLeastRecentlyUsed(t *testing.T) {
+	c := NewLineCache(3)
+	for n := 1; n <= 3; n++ {
+		c.Add(line(n), pts(n))
+	}
+	if got, want := cached(c), []Line{line(3), line(2), line(1)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("order %v, want %v", got, want)
+	}
+
+	// Get moves line 1 to the front, so line 2 is the next to go
+	if got, ok := c.Get(line(1)); !ok || !reflect.DeepEqual(got, pts(1)) {
+		t.Fatalf("Get(line 1) = %v, %v", got, ok)
+	}
+	c.Add(line(4), pts(4))
+	if got, want := cached(c), []Line{line(4), line(1), line(3)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after evicting, order %v, want %v", got, want)
+	}
+	if _, ok := c.Get(line(2)); ok {
+		t.Error("line 2 is still cached")
+	}
+
+	// Adding a cached line again replaces its points and moves it to the front, without evicting anything
+	c.Add(line(3), pts(30))
+	if got, want := cached(c), []Line{line(3), line(4), line(1)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after adding line 3 again, order %v, want %v", got, want)
+	}
+	if got, _ := c.Get(line(3)); !reflect.DeepEqual(got, pts(30)) {
+		t.Errorf("Get(line 3) = %v, want the new points", got)
+	}
+
+	want := CacheStats{Hits: 2, Misses: 1, Evictions: 1, Size: 3, Capacity: 3}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if len(c.entries) != c.order.Len() {
+		t.Errorf("%d entries in the map, %d in the list", len(c.entries), c.order.Len())
+	}
+}
+
+func TestNewLineCachePanicsWithoutCapacity(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewLineCache(0) did not panic")
+		}
+	}()
+	NewLineCache(0)
+}
+
+// Run with -race.
+func TestLineCacheFromManyGoroutines(t *testing.T) {
+	const goroutines, rounds, capacity = 8, 200, 16
+	c := NewLineCache(capacity)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				n := (g + i) % (2 * capacity) // Twice as many lines as fit, so there are hits and evictions
+				if got, ok := c.Get(line(n)); ok {
+					if !reflect.DeepEqual(got, pts(n)) {
+						t.Errorf("Get(line %d) = %v", n, got)
+					}
+					continue
+				}
+				c.Add(line(n), pts(n))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	s := c.Stats()
+	if s.Hits+s.Misses != goroutines*rounds {
+		t.Errorf("%d hits and %d misses, want %d lookups", s.Hits, s.Misses, goroutines*rounds)
+	}
+	if s.Hits == 0 || s.Evictions == 0 {
+		t.Errorf("Stats() = %+v, want both hits and evictions", s)
+	}
+	if s.Size != capacity || len(c.entries) != capacity || len(cached(c)) != capacity {
+		t.Errorf("Stats() = %+v with %d entries in the map", s, len(c.entries))
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/Germanchrystan/design_patterns_course
 
 go 1.18
+
+require github.com/mattn/go-sqlite3 v1.14.19